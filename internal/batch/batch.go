@@ -0,0 +1,338 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"warmy/internal/config"
+	wgit "warmy/internal/git"
+	"warmy/internal/logger"
+	"warmy/internal/output"
+	"warmy/internal/types"
+)
+
+// Run analyzes every repository listed in the configuration, writes one report
+// per repository and a combined summary report
+func Run(ctx context.Context, cfg *config.Config, log logger.Logger) (*types.BatchSummary, error) {
+	if len(cfg.Repos) == 0 {
+		return nil, fmt.Errorf("no repositories configured, add a repos list to the config file")
+	}
+
+	// Report directories are named after the repositories, so names must be unique
+	names := make(map[string]int, len(cfg.Repos))
+	for i, repoCfg := range cfg.Repos {
+		name := repoName(repoCfg, i)
+		if first, ok := names[name]; ok {
+			return nil, fmt.Errorf("repos[%d] and repos[%d] are both named %q, set repos[].name to tell them apart", first, i, name)
+		}
+		names[name] = i
+	}
+
+	baseConfig := *cfg
+
+	summary := &types.BatchSummary{
+		AnalyzeTime: time.Now().Format("20060102-150405"),
+		TotalRepos:  len(cfg.Repos),
+		Repos:       make([]types.BatchRepoResult, 0, len(cfg.Repos)),
+	}
+
+	for i, repoCfg := range cfg.Repos {
+		if ctx.Err() != nil {
+			break
+		}
+		name := repoName(repoCfg, i)
+
+		log.WithFields(logger.Fields{
+			"repo":   name,
+			"path":   repoCfg.Path,
+			"url":    repoCfg.URL,
+			"branch": repoCfg.Branch,
+		}).Info("Started analyzing repository")
+
+		result := analyzeRepo(ctx, &baseConfig, repoCfg, name, log)
+		if result.Error != "" {
+			summary.FailedRepos++
+			log.WithFields(logger.Fields{
+				"repo":  name,
+				"error": result.Error,
+			}).Error("Failed to analyze repository")
+		} else {
+			summary.SuccessRepos++
+			summary.FocusStats.Add(result.FocusStats)
+		}
+
+		summary.Repos = append(summary.Repos, result)
+	}
+
+	// Save combined summary
+	if !baseConfig.NoFile.Value() {
+		filename := fmt.Sprintf("batch-summary-%s.json", summary.AnalyzeTime)
+		summary.OutputFile = filename
+
+		jsonOutput, err := summary.ToJSON(baseConfig.PrettyJSON.Value())
+		if err != nil {
+			return summary, fmt.Errorf("failed to format summary JSON: %w", err)
+		}
+
+		fullPath, err := output.SaveJSONToFile(baseConfig.OutputDir, filename, jsonOutput)
+		if err != nil {
+			return summary, err
+		}
+
+		log.WithFields(logger.Fields{
+			"filename": filename,
+			"filepath": fullPath,
+		}).Info("Batch summary saved to file")
+	}
+
+	log.WithFields(logger.Fields{
+		"total_repos":       summary.TotalRepos,
+		"success_repos":     summary.SuccessRepos,
+		"failed_repos":      summary.FailedRepos,
+		"total_focus_files": summary.FocusStats.TotalFocusFiles,
+	}).Info("Batch analysis completed")
+
+	return summary, nil
+}
+
+// analyzeRepo analyzes a single repository and saves its report
+func analyzeRepo(ctx context.Context, baseConfig *config.Config, repoCfg config.RepoConfig, name string, log logger.Logger) types.BatchRepoResult {
+	log = log.WithFields(logger.Fields{"repo": name})
+
+	result := types.BatchRepoResult{
+		Name:   name,
+		Path:   repoCfg.Path,
+		URL:    repoCfg.URL,
+		Branch: repoCfg.Branch,
+	}
+
+	// Make local repository available
+	repoPath, err := prepareRepo(ctx, baseConfig, repoCfg, name, log)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Path = repoPath
+
+	// Resolve branch to commit hash. Cloned repositories always resolve through
+	// the remote tracking branch, their local HEAD is not moved by fetch
+	commitHash := repoCfg.CommitHash
+	cloned := repoCfg.Path == ""
+	if commitHash == "" && (repoCfg.Branch != "" || cloned) {
+		commitHash, err = resolveBranch(repoPath, repoCfg.Branch, cloned)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	// Build repository configuration with focus overrides
+	repoConfig := *baseConfig
+	repoConfig.RepoPath = repoPath
+	repoConfig.CommitHash = commitHash
+	repoConfig.Repos = nil
+	if err := repoCfg.MergeFocusInto(&repoConfig.Focus); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	analyzer, err := wgit.New(&repoConfig, log)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	commitInfo, err := analyzer.GetCommit(ctx, repoPath, commitHash)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Hash = commitInfo.Hash
+	result.ShortHash = commitInfo.ShortHash
+	result.Message = commitInfo.Message
+	result.Stats = commitInfo.Stats
+	result.FocusStats = commitInfo.FocusStats
+
+	// Save repository report
+	if !repoConfig.NoFile.Value() {
+		filename := fmt.Sprintf("%s-%s.json", commitInfo.ShortHash, commitInfo.AnalyzeTime)
+		commitInfo.OutputFile = filename
+
+		jsonOutput, err := commitInfo.ToJSON(repoConfig.PrettyJSON.Value())
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to format JSON: %v", err)
+			return result
+		}
+
+		fullPath, err := output.SaveJSONToFile(filepath.Join(repoConfig.OutputDir, name), filename, jsonOutput)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.OutputFile = fullPath
+
+		log.WithFields(logger.Fields{
+			"filename": filename,
+			"filepath": fullPath,
+		}).Info("JSON data saved to file")
+	}
+
+	return result
+}
+
+// prepareRepo returns local path of the repository, cloning or fetching remote repositories
+func prepareRepo(ctx context.Context, baseConfig *config.Config, repoCfg config.RepoConfig, name string, log logger.Logger) (string, error) {
+	if repoCfg.Path != "" {
+		return repoCfg.Path, nil
+	}
+	if repoCfg.URL == "" {
+		return "", fmt.Errorf("repository %s has neither path nor url", name)
+	}
+
+	log = log.WithFields(logger.Fields{
+		"url": repoCfg.URL,
+	})
+
+	clonePath := filepath.Join(baseConfig.CloneDir, cloneDirName(repoCfg.URL))
+
+	// Fetch updates if already cloned
+	if _, err := os.Stat(clonePath); err == nil {
+		repo, err := git.PlainOpen(clonePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to open cloned repository: %w", err)
+		}
+
+		// Never fetch into a clone of another repository
+		remote, err := repo.Remote(git.DefaultRemoteName)
+		if err != nil {
+			return "", fmt.Errorf("failed to get origin of cloned repository %s: %w", clonePath, err)
+		}
+		if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != repoCfg.URL {
+			return "", fmt.Errorf("cloned repository %s has origin %v, expected %s", clonePath, urls, repoCfg.URL)
+		}
+
+		log.Info("Fetching remote repository")
+		err = repo.FetchContext(ctx, &git.FetchOptions{Tags: git.AllTags, Force: true})
+		if err != nil && err != git.NoErrAlreadyUpToDate {
+			return "", fmt.Errorf("failed to fetch remote repository: %w", err)
+		}
+		return clonePath, nil
+	}
+
+	log.WithFields(logger.Fields{
+		"clone_path": clonePath,
+	}).Info("Cloning remote repository")
+
+	cloneOptions := &git.CloneOptions{URL: repoCfg.URL}
+	if repoCfg.Branch != "" {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(repoCfg.Branch)
+	}
+
+	if _, err := git.PlainCloneContext(ctx, clonePath, true, cloneOptions); err != nil {
+		return "", fmt.Errorf("failed to clone remote repository: %w", err)
+	}
+
+	return clonePath, nil
+}
+
+// resolveBranch resolves branch name to commit hash, an empty branch means the branch HEAD points to.
+// Remote tracking branches are preferred for cloned repositories as they are updated by fetch.
+func resolveBranch(repoPath, branch string, preferRemote bool) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open local repository: %w", err)
+	}
+
+	if branch == "" {
+		head, err := repo.Reference(plumbing.HEAD, false)
+		if err != nil {
+			return "", fmt.Errorf("failed to get HEAD reference: %w", err)
+		}
+		if head.Type() != plumbing.SymbolicReference {
+			return head.Hash().String(), nil
+		}
+		branch = head.Target().Short()
+	}
+
+	names := []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(branch),
+		plumbing.NewRemoteReferenceName("origin", branch),
+	}
+	if preferRemote {
+		names[0], names[1] = names[1], names[0]
+	}
+
+	for _, name := range names {
+		ref, err := repo.Reference(name, true)
+		if err == nil {
+			return ref.Hash().String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("branch not found: %s", branch)
+}
+
+// cloneDirName returns the clone directory of a remote URL relative to clone_dir,
+// made of the host and path of the URL so that repositories of different owners
+// with the same name get different directories, e.g. github.com/owner/repo
+func cloneDirName(url string) string {
+	source := url
+	if _, rest, ok := strings.Cut(source, "://"); ok {
+		source = rest
+	} else if host, path, ok := strings.Cut(source, ":"); ok && !strings.Contains(host, "/") {
+		// scp-like syntax, e.g. git@github.com:owner/repo.git
+		source = host + "/" + path
+	}
+	// Drop the user of the host, e.g. git@
+	host, path, _ := strings.Cut(source, "/")
+	if _, h, ok := strings.Cut(host, "@"); ok {
+		host = h
+	}
+	source = host + "/" + path
+	source = strings.TrimSuffix(strings.TrimRight(source, "/"), ".git")
+
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(source, ":", "/"), "/") {
+		if part != "" && part != "." && part != ".." {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		sum := sha256.Sum256([]byte(url))
+		return hex.EncodeToString(sum[:8])
+	}
+	return filepath.Join(parts...)
+}
+
+// repoName returns configured repository name or derives it from path or URL
+func repoName(repoCfg config.RepoConfig, index int) string {
+	if repoCfg.Name != "" {
+		return repoCfg.Name
+	}
+
+	source := repoCfg.Path
+	if source == "" {
+		source = repoCfg.URL
+	}
+	source = strings.TrimRight(source, "/")
+	source = strings.TrimSuffix(source, ".git")
+
+	name := filepath.Base(source)
+	if idx := strings.LastIndexAny(name, ":/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return fmt.Sprintf("repo-%d", index+1)
+	}
+	return name
+}
@@ -4,101 +4,369 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // FocusConfig focus configuration
 type FocusConfig struct {
-	Enable         bool     `json:"enable,omitempty"`          // Whether to enable focus
-	AddFiles       bool     `json:"add_files,omitempty"`       // Whether to focus on new files
-	ModifyFiles    bool     `json:"modify_files,omitempty"`    // Whether to focus on modified files
-	DeleteFiles    bool     `json:"delete_files,omitempty"`    // Whether to focus on deleted files
-	FilePatterns   []string `json:"file_patterns,omitempty"`   // File path matching patterns
-	IgnorePatterns []string `json:"ignore_patterns,omitempty"` // Ignore patterns
+	Enable             OptionalBool  `json:"enable,omitzero"`               // Whether to enable focus
+	AddFiles           OptionalBool  `json:"add_files,omitzero"`            // Whether to focus on new files
+	ModifyFiles        OptionalBool  `json:"modify_files,omitzero"`         // Whether to focus on modified files
+	DeleteFiles        OptionalBool  `json:"delete_files,omitzero"`         // Whether to focus on deleted files
+	ModeChanges        OptionalBool  `json:"mode_changes,omitzero"`         // Whether to focus on file mode changes
+	SymlinkChanges     OptionalBool  `json:"symlink_changes,omitzero"`      // Whether to focus on symlink changes
+	SubmoduleChanges   OptionalBool  `json:"submodule_changes,omitzero"`    // Whether to focus on submodule changes
+	FilePatterns       []string      `json:"file_patterns,omitempty"`       // File path matching patterns
+	IgnorePatterns     []string      `json:"ignore_patterns,omitempty"`     // Ignore patterns
+	IgnoreAttributes   []string      `json:"ignore_attributes,omitempty"`   // Files with any of these gitattributes are never focus
+	RequireAttributes  []string      `json:"require_attributes,omitempty"`  // Only files with all of these gitattributes can be focus
+	MessageRules       []MessageRule `json:"message_rules,omitempty"`       // Rules matching the commit message
+	TrustedAuthors     []string      `json:"trusted_authors,omitempty"`     // Regular expressions of author names or emails whose focus files are low severity
+	UntrustedAuthors   []string      `json:"untrusted_authors,omitempty"`   // Regular expressions of author names or emails whose focus files are high severity
+	NewContributors    OptionalBool  `json:"new_contributors,omitzero"`     // Whether focus files of the first commit by an author email are high severity
+	EscalateSignatures []string      `json:"escalate_signatures,omitempty"` // Signature statuses whose focus files are high severity: unsigned, bad, unknown_key, unverified
+	SeverityChanges    []string      `json:"severity_changes,omitempty"`    // Severity change directions that make a file focus: escalation, de-escalation, changed
+}
+
+// MessageRule focus rule matching a field of the commit message
+type MessageRule struct {
+	Name    string `json:"name,omitempty"`   // Rule name, recorded as focus reason
+	Pattern string `json:"pattern"`          // Regular expression matched against the field
+	Field   string `json:"field,omitempty"`  // Matched field: message, description, full_message (default), type or scope
+	Action  string `json:"action,omitempty"` // focus (default) marks the commit as focus, suppress drops the focus of its files
+}
+
+// RepoConfig repository entry for batch analysis
+type RepoConfig struct {
+	Name       string      `json:"name,omitempty"`        // Repository name, used as report sub directory
+	Path       string      `json:"path,omitempty"`        // Local repository path
+	URL        string      `json:"url,omitempty"`         // Remote repository URL, cloned into clone_dir when path is empty
+	Branch     string      `json:"branch,omitempty"`      // Branch to analyze, defaults to HEAD
+	CommitHash string      `json:"commit_hash,omitempty"` // Specify commit hash, takes precedence over branch
+	Focus      FocusConfig `json:"focus,omitzero"`        // Focus configuration overrides
+
+	focus json.RawMessage // Focus overrides as written in the config file
+}
+
+// UnmarshalJSON decodes a repository and keeps its focus overrides as written, so
+// they are merged by the keys they set
+func (r *RepoConfig) UnmarshalJSON(data []byte) error {
+	type plain RepoConfig
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	var raw struct {
+		Focus json.RawMessage `json:"focus"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = RepoConfig(decoded)
+	r.focus = raw.Focus
+	return nil
+}
+
+// MergeFocusInto merges the focus overrides of the repository over dst. Overrides
+// decoded from a config file also set zero values, see MergeJSON.
+func (r *RepoConfig) MergeFocusInto(dst *FocusConfig) error {
+	if r.focus == nil {
+		MergeFocus(dst, &r.Focus)
+		return nil
+	}
+	if err := MergeJSON(dst, r.focus); err != nil {
+		return fmt.Errorf("invalid focus overrides: %w", err)
+	}
+	return nil
 }
 
 // Config configuration parameters
 type Config struct {
-	RepoPath        string      `json:"repo_path,omitempty"`
-	CommitHash      string      `json:"commit_hash,omitempty"` // Specify commit hash
-	OutputFormat    string      `json:"output_format,omitempty"`
-	PrettyJSON      bool        `json:"pretty_json,omitempty"`
-	MaxDiffSize     int         `json:"max_diff_size,omitempty"`
-	IncludeFullDiff bool        `json:"include_full_diff,omitempty"`
-	Verbose         bool        `json:"verbose,omitempty"`
-	ParseDiff       bool        `json:"parse_diff,omitempty"`  // Whether to parse diff content
-	OutputDir       string      `json:"output_dir,omitempty"`  // Output directory
-	NoFile          bool        `json:"no_file,omitempty"`     // Do not output to file
-	NoConsole       bool        `json:"no_console,omitempty"`  // Do not output to console
-	LogLevel        string      `json:"log_level,omitempty"`   // Log level
-	ConfigFile      string      `json:"config_file,omitempty"` // Config file path
-	Focus           FocusConfig `json:"focus,omitempty"`       // Focus configuration
-}
-
-// Global configuration variable
-var globalConfig = Config{
-	MaxDiffSize:     1024 * 1024, // Default 1MB
-	IncludeFullDiff: false,
-	PrettyJSON:      true,
-	Verbose:         false,
-	ParseDiff:       true, // Default parse diff
-	OutputDir:       ".",  // Default current directory
-	NoFile:          false,
-	NoConsole:       false,
-	LogLevel:        "info", // Default log level
-	ConfigFile:      "",     // Default no config file
-	Focus: FocusConfig{
-		Enable:      true,
-		AddFiles:    true,
-		ModifyFiles: true,
-		DeleteFiles: true, // Add delete files focus
-		// FilePatterns and IgnorePatterns are now empty by default
-		// They must be provided in the config file if focus is enabled
-	},
-}
-
-// SetConfigFile sets config file path
-func SetConfigFile(filename string) {
-	globalConfig.ConfigFile = filename
-}
-
-// GetConfig gets current configuration
-func GetConfig() *Config {
-	return &globalConfig
-}
-
-// LoadConfig loads configuration from file
-func LoadConfig() (*Config, error) {
+	RepoPath         string                 `json:"repo_path,omitempty"`
+	CommitHash       string                 `json:"commit_hash,omitempty"` // Specify commit hash
+	OutputFormat     string                 `json:"output_format,omitempty"`
+	PrettyJSON       OptionalBool           `json:"pretty_json,omitzero"`
+	MaxDiffSize      int                    `json:"max_diff_size,omitempty"`
+	MaxTotalDiffSize int                    `json:"max_total_diff_size,omitempty"` // Diff content budget of a report over all files, 0 means no limit
+	TruncateHunks    int                    `json:"truncate_hunks,omitempty"`      // Hunks kept from the start and the end of a truncated diff
+	IncludeFullDiff  OptionalBool           `json:"include_full_diff,omitzero"`
+	Verbose          OptionalBool           `json:"verbose,omitzero"`
+	ParseDiff        OptionalBool           `json:"parse_diff,omitzero"`        // Whether to parse diff content
+	OutputDir        string                 `json:"output_dir,omitempty"`       // Output directory
+	NoFile           OptionalBool           `json:"no_file,omitzero"`           // Do not output to file
+	NoConsole        OptionalBool           `json:"no_console,omitzero"`        // Do not output to console
+	LogLevel         string                 `json:"log_level,omitempty"`        // Log level
+	Workers          int                    `json:"workers,omitempty"`          // Number of file processing workers, 0 means number of CPUs
+	CommitRange      string                 `json:"commit_range,omitempty"`     // Commit range for range analysis, e.g. "v1.0.0..HEAD"
+	MaxCommits       int                    `json:"max_commits,omitempty"`      // Maximum number of commits in range analysis, 0 means no limit
+	CommitWorkers    int                    `json:"commit_workers,omitempty"`   // Number of commit analysis workers, 0 means number of CPUs
+	MergeStrategy    string                 `json:"merge_strategy,omitempty"`   // How merge commits are compared with their parents
+	RootCommit       RootCommitConfig       `json:"root_commit,omitzero"`       // Root commit analysis
+	LFS              LFSConfig              `json:"lfs,omitzero"`               // Git LFS pointer file handling
+	Paths            PathFilterConfig       `json:"paths,omitzero"`             // Paths included in the analysis
+	CommitFilter     CommitFilterConfig     `json:"commit_filter,omitzero"`     // Commits included in range analysis
+	Signatures       SignatureConfig        `json:"signatures,omitzero"`        // Keys verifying commit and tag signatures
+	Secrets          SecretsConfig          `json:"secrets,omitzero"`           // Secret scanning of added lines
+	Templates        TemplateConfig         `json:"templates,omitzero"`         // Nuclei template analysis
+	SeverityTracking SeverityTrackingConfig `json:"severity_tracking,omitzero"` // Severity changes of structured files
+	Feed             FeedConfig             `json:"feed,omitzero"`              // Vulnerability feed of the feed command
+	Progress         OptionalBool           `json:"progress,omitzero"`          // Show progress indicator on stderr in range analysis
+	ConfigFile       string                 `json:"config_file,omitempty"`      // Config file path
+	Focus            FocusConfig            `json:"focus,omitzero"`             // Focus configuration
+	Repos            []RepoConfig           `json:"repos,omitempty"`            // Repositories for batch analysis
+	CloneDir         string                 `json:"clone_dir,omitempty"`        // Directory for cloned remote repositories
+
+	Include  []string           `json:"include,omitempty"`  // Shared config files merged before this file
+	Profile  string             `json:"profile,omitempty"`  // Selected profile name
+	Profiles map[string]Profile `json:"profiles,omitempty"` // Named configuration profiles
+}
+
+// Merge strategies for comparing merge commits with their parents
+const (
+	MergeFirstParent = "first-parent" // Diff against the first parent
+	MergeEachParent  = "each-parent"  // Diff against every parent
+	MergeCombined    = "combined"     // Only files differing from all parents, diffed against the first parent
+	MergeSkip        = "skip"         // Do not analyze changes of merge commits
+)
+
+// Root commit modes
+const (
+	RootCommitSummary = "summary" // List files with size, line count and blob hash
+	RootCommitFull    = "full"    // Include complete file contents
+)
+
+// RootCommitConfig root commit analysis configuration
+// A root commit has no parent, so every file of the repository is an added file.
+type RootCommitConfig struct {
+	Mode        string `json:"mode,omitempty"`         // Root commit mode, "summary" or "full"
+	SampleLines int    `json:"sample_lines,omitempty"` // Leading lines of each text file included in summary mode
+}
+
+// LFSConfig Git LFS configuration
+// Changes of LFS pointer files are always reported with their object ids and sizes.
+type LFSConfig struct {
+	Resolve OptionalBool `json:"resolve,omitzero"` // Diff LFS objects found in the local .git/lfs/objects store
+}
+
+// PathFilterConfig analysis path filters
+// Filtered paths are dropped before diffing, unlike focus patterns which only mark changes.
+type PathFilterConfig struct {
+	Include      []string     `json:"include,omitempty"`       // Globs of paths to analyze, all paths if empty
+	Exclude      []string     `json:"exclude,omitempty"`       // Globs of paths to skip
+	IncludeRegex []string     `json:"include_regex,omitempty"` // Regular expressions of paths to analyze
+	ExcludeRegex []string     `json:"exclude_regex,omitempty"` // Regular expressions of paths to skip
+	IgnoreFile   OptionalBool `json:"ignore_file,omitzero"`    // Whether to skip paths listed in the .warmyignore file of the repository
+}
+
+// SecretsConfig secret scanning of added lines
+type SecretsConfig struct {
+	Enable     OptionalBool     `json:"enable,omitzero"`       // Whether to scan added lines for secrets
+	Detectors  []string         `json:"detectors,omitempty"`   // Built-in detectors to run, all if empty
+	Custom     []SecretDetector `json:"custom,omitempty"`      // Custom regular expression detectors
+	MinEntropy float64          `json:"min_entropy,omitempty"` // Minimum Shannon entropy (bits per character) of high entropy strings
+	Redact     OptionalBool     `json:"redact,omitzero"`       // Whether to redact secrets from diff content
+}
+
+// SeverityTrackingConfig severity changes of YAML and JSON files
+type SeverityTrackingConfig struct {
+	Enable OptionalBool `json:"enable,omitzero"` // Whether to track severity changes
+	Field  string       `json:"field,omitempty"` // Dotted path of the severity field, e.g. info.severity
+	Scale  []string     `json:"scale,omitempty"` // Severities from lowest to highest
+}
+
+// TemplateConfig nuclei template analysis of changed YAML files
+type TemplateConfig struct {
+	Enable OptionalBool `json:"enable,omitzero"` // Whether to parse changed YAML files as nuclei templates
+}
+
+// Feed formats
+const (
+	FeedJSON = "json" // JSON Feed 1.1
+	FeedAtom = "atom" // Atom 1.0
+	FeedRSS  = "rss"  // RSS 2.0
+)
+
+// FeedConfig vulnerability feed of the feed command
+type FeedConfig struct {
+	Formats         []string `json:"formats,omitempty"`          // Feed formats to write: json, atom, rss
+	Title           string   `json:"title,omitempty"`            // Feed title
+	Link            string   `json:"link,omitempty"`             // Home page URL of the feed
+	CommitURL       string   `json:"commit_url,omitempty"`       // Commit URL of entries, {hash} is replaced by the commit hash
+	MaterialChanges []string `json:"material_changes,omitempty"` // Template level changes that make a modified template a feed entry
+}
+
+// SecretDetector custom secret detector
+type SecretDetector struct {
+	Name    string `json:"name"`    // Detector name reported in findings
+	Pattern string `json:"pattern"` // Regular expression, the first capture group is the secret if there is one
+}
+
+// SignatureConfig keys of commit and tag signature verification.
+// Signatures of a format without configured keys are reported as unverified.
+type SignatureConfig struct {
+	Keyring        string `json:"keyring,omitempty"`         // OpenPGP public keyring file, armored or binary
+	AllowedSigners string `json:"allowed_signers,omitempty"` // SSH allowed signers file, the format of git's gpg.ssh.allowedSignersFile
+}
+
+// CommitFilterConfig commit filters of range analysis
+// Commits are filtered on their metadata before diffing, skipped commits are listed
+// in the range summary with the reason.
+type CommitFilterConfig struct {
+	Authors           []string `json:"authors,omitempty"`            // Regular expressions of author names or emails to analyze, all if empty
+	ExcludeAuthors    []string `json:"exclude_authors,omitempty"`    // Regular expressions of author names or emails to skip
+	Committers        []string `json:"committers,omitempty"`         // Regular expressions of committer names or emails to analyze, all if empty
+	ExcludeCommitters []string `json:"exclude_committers,omitempty"` // Regular expressions of committer names or emails to skip
+	Messages          []string `json:"messages,omitempty"`           // Regular expressions of commit messages to analyze, all if empty
+	ExcludeMessages   []string `json:"exclude_messages,omitempty"`   // Regular expressions of commit messages to skip
+	Types             []string `json:"types,omitempty"`              // Conventional Commits types to analyze, all commits if empty
+	ExcludeTypes      []string `json:"exclude_types,omitempty"`      // Conventional Commits types to skip
+	Since             string   `json:"since,omitempty"`              // Skip commits committed before, RFC 3339 time or YYYY-MM-DD date
+	Until             string   `json:"until,omitempty"`              // Skip commits committed after, RFC 3339 time or YYYY-MM-DD date
+	MinParents        int      `json:"min_parents,omitempty"`        // Skip commits with fewer parents
+	MaxParents        int      `json:"max_parents,omitempty"`        // Skip commits with more parents, 0 means no limit, 1 skips merges
+}
+
+// Profile named configuration profile
+// A profile holds any subset of the configuration parameters and is merged
+// over the profile it extends, or over the base configuration if Extends is empty.
+type Profile struct {
+	Extends string `json:"extends,omitempty"` // Parent profile name
+	Config
+
+	raw json.RawMessage // Profile as written in the config file
+}
+
+// UnmarshalJSON decodes a profile and keeps it as written, so it is merged by the
+// keys it sets
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	type plain Profile
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*p = Profile(decoded)
+	p.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// stderrIsTerminal reports whether stderr is a terminal, the progress indicator is
+// shown by default only then so that redirected output stays free of it
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// DefaultConfig returns the built-in default configuration.
+// Values loaded from a config file are merged over these defaults.
+func DefaultConfig() Config {
+	return Config{
+		MaxDiffSize:     1024 * 1024, // Default 1MB
+		TruncateHunks:   3,
+		IncludeFullDiff: Bool(false),
+		PrettyJSON:      Bool(true),
+		Verbose:         Bool(false),
+		ParseDiff:       Bool(true), // Default parse diff
+		OutputDir:       ".",        // Default current directory
+		CloneDir:        ".warmy/repos",
+		NoFile:          Bool(false),
+		NoConsole:       Bool(false),
+		LogLevel:        "info", // Default log level
+		Progress:        Bool(stderrIsTerminal()),
+		MergeStrategy:   MergeFirstParent,
+		RootCommit: RootCommitConfig{
+			Mode: RootCommitSummary,
+		},
+		Paths: PathFilterConfig{
+			IgnoreFile: Bool(true),
+		},
+		Secrets: SecretsConfig{
+			MinEntropy: 4.5,
+		},
+		SeverityTracking: SeverityTrackingConfig{
+			Field: "info.severity",
+			Scale: []string{"unknown", "info", "low", "medium", "high", "critical"},
+		},
+		Feed: FeedConfig{
+			Formats:         []string{FeedJSON},
+			Title:           "warmy vulnerability feed",
+			MaterialChanges: []string{"severity_changed", "matcher_changed", "classification_changed"},
+		},
+		ConfigFile: "", // Default no config file
+		Focus: FocusConfig{
+			Enable:      Bool(true),
+			AddFiles:    Bool(true),
+			ModifyFiles: Bool(true),
+			DeleteFiles: Bool(true), // Add delete files focus
+			// Mode, symlink and submodule changes can change behavior without changing content
+			ModeChanges:      Bool(true),
+			SymlinkChanges:   Bool(true),
+			SubmoduleChanges: Bool(true),
+			// Generated and vendored files are not hand-written changes
+			IgnoreAttributes: []string{"linguist-generated", "linguist-vendored"},
+			// FilePatterns and IgnorePatterns are now empty by default
+			// They must be provided in the config file if focus is enabled
+		},
+	}
+}
+
+// LoadConfig loads configuration from file, an empty configFile means config.json in current directory.
+// A non-empty profile overrides the profile selected in the config file.
+func LoadConfig(configFile, profile string) (*Config, error) {
 	// Find config file
-	configFile, err := findConfigFile()
+	configFile, err := findConfigFile(configFile)
 	if err != nil {
 		return nil, err
 	}
 
-	// Load config from file
-	fileConfig, err := loadConfigFromFile(configFile)
+	// Load config documents of the file and its shared files, included files first
+	layers, err := loadConfigWithIncludes(configFile, make(map[string]bool))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config file: %w", err)
 	}
 
-	// Update global config
-	globalConfig = *fileConfig
-	globalConfig.ConfigFile = configFile
+	// Merge file values over the built-in defaults
+	merged := DefaultConfig()
+	for _, layer := range layers {
+		if err := MergeJSON(&merged, layer); err != nil {
+			return nil, fmt.Errorf("failed to merge config file: %w", err)
+		}
+	}
+	merged.Include = nil
+
+	// Profile from command line takes precedence over the config file
+	if profile == "" {
+		profile = merged.Profile
+	}
+
+	// Apply selected profile
+	if profile != "" {
+		if err := applyProfile(&merged, profile); err != nil {
+			return nil, err
+		}
+	}
 
-	return &globalConfig, nil
+	merged.ConfigFile = configFile
+	merged.Profile = profile
+
+	return &merged, nil
 }
 
 // findConfigFile finds config file
-func findConfigFile() (string, error) {
+func findConfigFile(configFile string) (string, error) {
 	// If command line specifies config file, return directly
-	if globalConfig.ConfigFile != "" {
-		if _, err := os.Stat(globalConfig.ConfigFile); err == nil {
-			return globalConfig.ConfigFile, nil
+	if configFile != "" {
+		if _, err := os.Stat(configFile); err == nil {
+			return configFile, nil
 		}
-		return "", fmt.Errorf("specified config file does not exist: %s", globalConfig.ConfigFile)
+		return "", fmt.Errorf("specified config file does not exist: %s", configFile)
 	}
 
 	// Only look for config.json in current directory
-	configFile := "config.json"
+	configFile = "config.json"
 	if _, err := os.Stat(configFile); err == nil {
 		return configFile, nil
 	}
@@ -106,18 +374,114 @@ func findConfigFile() (string, error) {
 	return "", fmt.Errorf("config file not found: %s", configFile)
 }
 
-// loadConfigFromFile loads configuration from file
-func loadConfigFromFile(filename string) (*Config, error) {
-	data, err := os.ReadFile(filename)
+// loadConfigWithIncludes loads a config file and the files it includes and returns
+// their documents in merge order, included files before the including file.
+// Include paths are relative to the directory of the including file.
+func loadConfigWithIncludes(filename string, loading map[string]bool) ([]json.RawMessage, error) {
+	absPath, err := filepath.Abs(filename)
 	if err != nil {
 		return nil, err
 	}
+	if loading[absPath] {
+		return nil, fmt.Errorf("config include cycle detected: %s", filename)
+	}
+	loading[absPath] = true
+	defer delete(loading, absPath)
+
+	fileConfig, data, err := loadConfigFromFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var layers []json.RawMessage
+	for _, include := range fileConfig.Include {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(filename), includePath)
+		}
+
+		includeLayers, err := loadConfigWithIncludes(includePath, loading)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load include %s: %w", include, err)
+		}
+		layers = append(layers, includeLayers...)
+	}
+
+	return append(layers, data), nil
+}
+
+// applyProfile merges the named profile, and the profiles it extends, over cfg
+func applyProfile(cfg *Config, name string) error {
+	chain := make([]Profile, 0)
+	visited := make(map[string]bool)
+
+	for current := name; current != ""; {
+		if visited[current] {
+			return fmt.Errorf("profile inheritance cycle detected at profile: %s", current)
+		}
+		visited[current] = true
+
+		profile, ok := cfg.Profiles[current]
+		if !ok {
+			if current == name {
+				return fmt.Errorf("profile not found: %s (available: %s)", name, strings.Join(profileNames(cfg.Profiles), ", "))
+			}
+			return fmt.Errorf("profile %s extends unknown profile: %s", name, current)
+		}
+
+		// Includes and profiles are resolved before profiles are applied
+		switch {
+		case len(profile.Include) > 0:
+			return fmt.Errorf("profile %s sets include, includes are only allowed at the top level", current)
+		case len(profile.Profiles) > 0:
+			return fmt.Errorf("profile %s sets profiles, profiles cannot be nested", current)
+		case profile.Profile != "":
+			return fmt.Errorf("profile %s sets profile, use extends to build on another profile", current)
+		}
+
+		chain = append(chain, profile)
+		current = profile.Extends
+	}
+
+	// Apply from the outermost parent down to the selected profile, profiles read
+	// from a config file are merged by the keys they set
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].raw == nil {
+			layer := chain[i].Config
+			Merge(cfg, &layer)
+			continue
+		}
+		if err := MergeJSON(cfg, chain[i].raw); err != nil {
+			return fmt.Errorf("failed to apply profile %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// profileNames returns sorted profile names
+func profileNames(profiles map[string]Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadConfigFromFile loads configuration from file, it returns the decoded
+// configuration and the document as written
+func loadConfigFromFile(filename string) (*Config, json.RawMessage, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var config Config
 	err = json.Unmarshal(data, &config)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &config, nil
+	return &config, data, nil
 }
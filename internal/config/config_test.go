@@ -0,0 +1,350 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles writes config files into a temporary directory and returns it
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMergeJSON(t *testing.T) {
+	base := func() Config {
+		cfg := DefaultConfig()
+		cfg.MaxCommits = 5
+		cfg.Workers = 4
+		cfg.OutputDir = "out"
+		cfg.Focus.FilePatterns = []string{"a", "b"}
+		cfg.Feed.CommitURL = "https://example.com/{hash}"
+		cfg.Profiles = map[string]Profile{"keep": {Extends: "x"}}
+		return cfg
+	}
+
+	tests := []struct {
+		name  string
+		layer string
+		check func(t *testing.T, cfg Config)
+	}{
+		{
+			name:  "omitted keys keep the lower layer",
+			layer: `{}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.MaxCommits != 5 || cfg.OutputDir != "out" || !cfg.PrettyJSON.Value() {
+					t.Errorf("lower layer changed: max_commits=%d output_dir=%q pretty_json=%v", cfg.MaxCommits, cfg.OutputDir, cfg.PrettyJSON)
+				}
+			},
+		},
+		{
+			name:  "zero values override",
+			layer: `{"max_commits": 0, "workers": 0, "output_dir": "", "pretty_json": false, "feed": {"commit_url": ""}}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.MaxCommits != 0 || cfg.Workers != 0 || cfg.OutputDir != "" || cfg.Feed.CommitURL != "" {
+					t.Errorf("zero values not applied: max_commits=%d workers=%d output_dir=%q commit_url=%q",
+						cfg.MaxCommits, cfg.Workers, cfg.OutputDir, cfg.Feed.CommitURL)
+				}
+				if !cfg.PrettyJSON.IsSet() || cfg.PrettyJSON.Value() {
+					t.Errorf("pretty_json = %v, want explicit false", cfg.PrettyJSON)
+				}
+			},
+		},
+		{
+			name:  "null keeps the lower layer",
+			layer: `{"max_commits": null, "pretty_json": null, "focus": null}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.MaxCommits != 5 || !cfg.PrettyJSON.Value() || len(cfg.Focus.FilePatterns) != 2 {
+					t.Errorf("null changed the lower layer: max_commits=%d pretty_json=%v file_patterns=%v",
+						cfg.MaxCommits, cfg.PrettyJSON, cfg.Focus.FilePatterns)
+				}
+			},
+		},
+		{
+			name:  "nested objects merge key by key",
+			layer: `{"focus": {"enable": true}}`,
+			check: func(t *testing.T, cfg Config) {
+				if !cfg.Focus.Enable.Value() {
+					t.Error("focus.enable not applied")
+				}
+				if !reflect.DeepEqual(cfg.Focus.FilePatterns, []string{"a", "b"}) {
+					t.Errorf("focus.file_patterns = %v, want lower layer", cfg.Focus.FilePatterns)
+				}
+				if !cfg.Focus.AddFiles.Value() {
+					t.Error("focus.add_files default lost")
+				}
+			},
+		},
+		{
+			name:  "lists are replaced",
+			layer: `{"focus": {"file_patterns": []}}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Focus.FilePatterns == nil || len(cfg.Focus.FilePatterns) != 0 {
+					t.Errorf("focus.file_patterns = %#v, want empty list", cfg.Focus.FilePatterns)
+				}
+			},
+		},
+		{
+			name:  "maps merge by entry",
+			layer: `{"profiles": {"added": {"max_commits": 1}}}`,
+			check: func(t *testing.T, cfg Config) {
+				if _, ok := cfg.Profiles["keep"]; !ok {
+					t.Error("profile of the lower layer dropped")
+				}
+				if cfg.Profiles["added"].MaxCommits != 1 {
+					t.Errorf("profiles.added.max_commits = %d, want 1", cfg.Profiles["added"].MaxCommits)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			if err := MergeJSON(&cfg, []byte(tt.layer)); err != nil {
+				t.Fatalf("MergeJSON: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestMergeJSONDoesNotWriteLowerLayerSlices(t *testing.T) {
+	patterns := make([]string, 2, 8)
+	copy(patterns, []string{"a", "b"})
+	lower := FocusConfig{FilePatterns: patterns}
+
+	upper := lower
+	if err := MergeJSON(&upper, []byte(`{"file_patterns": ["c"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(upper.FilePatterns, []string{"c"}) {
+		t.Errorf("merged file_patterns = %v, want [c]", upper.FilePatterns)
+	}
+	if got := patterns[:1]; got[0] != "a" {
+		t.Errorf("lower layer slice overwritten: %v", got)
+	}
+}
+
+func TestMergeJSONInvalidValue(t *testing.T) {
+	cfg := DefaultConfig()
+	err := MergeJSON(&cfg, []byte(`{"focus": {"file_patterns": "a"}}`))
+	if err == nil || !strings.Contains(err.Error(), "focus: file_patterns") {
+		t.Errorf("error = %v, want path focus: file_patterns", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dst := DefaultConfig()
+	dst.MaxCommits = 5
+	overlay := Config{OutputDir: "reports", PrettyJSON: Bool(false)}
+
+	Merge(&dst, &overlay)
+
+	if dst.OutputDir != "reports" || dst.PrettyJSON.Value() {
+		t.Errorf("overlay not applied: output_dir=%q pretty_json=%v", dst.OutputDir, dst.PrettyJSON)
+	}
+	// Programmatic overlays treat zero values as unset
+	if dst.MaxCommits != 5 || dst.MaxDiffSize != 1024*1024 {
+		t.Errorf("unset overlay values changed dst: max_commits=%d max_diff_size=%d", dst.MaxCommits, dst.MaxDiffSize)
+	}
+}
+
+func TestLoadConfigLayering(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"shared/base.json": `{
+			"output_dir": "shared-out",
+			"max_commits": 10,
+			"workers": 2,
+			"focus": {"enable": true, "file_patterns": ["\\.yaml$"]}
+		}`,
+		"shared/more.json": `{"include": ["base.json"], "log_level": "debug"}`,
+		"config.json": `{
+			"include": ["shared/more.json"],
+			"max_commits": 20,
+			"profile": "fast",
+			"profiles": {
+				"base": {"workers": 8, "output_dir": "base-out"},
+				"fast": {"extends": "base", "max_commits": 0, "focus": {"modify_files": false}},
+				"other": {"output_dir": "other-out"}
+			}
+		}`,
+	})
+	configFile := filepath.Join(dir, "config.json")
+
+	tests := []struct {
+		name    string
+		profile string
+		expect  map[string]any
+	}{
+		{
+			name:    "profile from file with extends",
+			profile: "",
+			expect: map[string]any{
+				"profile":             "fast",
+				"max_commits":         0,           // fast resets the file value
+				"workers":             8,           // base profile over include
+				"output_dir":          "base-out",  // base profile over include
+				"log_level":           "debug",     // nested include over default
+				"max_diff_size":       1024 * 1024, // default
+				"focus.enable":        true,        // include
+				"focus.modify_files":  false,       // fast profile over default
+				"focus.add_files":     true,        // default
+				"focus.file_patterns": []string{`\.yaml$`},
+			},
+		},
+		{
+			name:    "profile from command line",
+			profile: "other",
+			expect: map[string]any{
+				"profile":             "other",
+				"max_commits":         20,          // file over include
+				"workers":             2,           // include
+				"output_dir":          "other-out", // profile
+				"log_level":           "debug",
+				"max_diff_size":       1024 * 1024,
+				"focus.enable":        true,
+				"focus.modify_files":  true,
+				"focus.add_files":     true,
+				"focus.file_patterns": []string{`\.yaml$`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(configFile, tt.profile)
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+
+			got := map[string]any{
+				"profile":             cfg.Profile,
+				"max_commits":         cfg.MaxCommits,
+				"workers":             cfg.Workers,
+				"output_dir":          cfg.OutputDir,
+				"log_level":           cfg.LogLevel,
+				"max_diff_size":       cfg.MaxDiffSize,
+				"focus.enable":        cfg.Focus.Enable.Value(),
+				"focus.modify_files":  cfg.Focus.ModifyFiles.Value(),
+				"focus.add_files":     cfg.Focus.AddFiles.Value(),
+				"focus.file_patterns": cfg.Focus.FilePatterns,
+			}
+			for key, want := range tt.expect {
+				if !reflect.DeepEqual(got[key], want) {
+					t.Errorf("%s = %#v, want %#v", key, got[key], want)
+				}
+			}
+			if cfg.Include != nil {
+				t.Errorf("include = %v, want nil after loading", cfg.Include)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		profile string
+		wantErr string
+	}{
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"config.json": `{"include": ["a.json"]}`,
+				"a.json":      `{"include": ["b.json"]}`,
+				"b.json":      `{"include": ["a.json"]}`,
+			},
+			wantErr: "config include cycle detected",
+		},
+		{
+			name:    "include of itself",
+			files:   map[string]string{"config.json": `{"include": ["config.json"]}`},
+			wantErr: "config include cycle detected",
+		},
+		{
+			name:    "missing include",
+			files:   map[string]string{"config.json": `{"include": ["missing.json"]}`},
+			wantErr: "failed to load include missing.json",
+		},
+		{
+			name:    "unknown profile",
+			files:   map[string]string{"config.json": `{"profiles": {"a": {}}}`},
+			profile: "b",
+			wantErr: "profile not found: b (available: a)",
+		},
+		{
+			name:    "extends unknown profile",
+			files:   map[string]string{"config.json": `{"profiles": {"a": {"extends": "b"}}}`},
+			profile: "a",
+			wantErr: "profile a extends unknown profile: b",
+		},
+		{
+			name:    "extends cycle",
+			files:   map[string]string{"config.json": `{"profiles": {"a": {"extends": "b"}, "b": {"extends": "a"}}}`},
+			profile: "a",
+			wantErr: "profile inheritance cycle detected at profile: a",
+		},
+		{
+			name:    "include in profile",
+			files:   map[string]string{"config.json": `{"profiles": {"a": {"include": ["x.json"]}}}`},
+			profile: "a",
+			wantErr: "profile a sets include",
+		},
+		{
+			name:    "nested profiles",
+			files:   map[string]string{"config.json": `{"profiles": {"a": {"profiles": {"b": {}}}}}`},
+			profile: "a",
+			wantErr: "profile a sets profiles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := LoadConfig(filepath.Join(dir, "config.json"), tt.profile)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepoConfigMergeFocusInto(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.json": `{
+			"focus": {"enable": true, "file_patterns": ["a"], "modify_files": true},
+			"repos": [{"name": "r", "path": ".", "focus": {"modify_files": false, "file_patterns": []}}]
+		}`,
+	})
+	cfg, err := LoadConfig(filepath.Join(dir, "config.json"), "")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	focus := cfg.Focus
+	if err := cfg.Repos[0].MergeFocusInto(&focus); err != nil {
+		t.Fatalf("MergeFocusInto: %v", err)
+	}
+	if !focus.Enable.Value() || focus.ModifyFiles.Value() || len(focus.FilePatterns) != 0 {
+		t.Errorf("repository focus = enable %v, modify_files %v, file_patterns %v; want true, false, []",
+			focus.Enable, focus.ModifyFiles, focus.FilePatterns)
+	}
+	if !reflect.DeepEqual(cfg.Focus.FilePatterns, []string{"a"}) {
+		t.Errorf("top-level focus changed: %v", cfg.Focus.FilePatterns)
+	}
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OptionalBool is a tri-state boolean that distinguishes "unset" from "false".
+// The zero value is unset, so fields omitted from a config file do not
+// override the value of a lower configuration layer.
+type OptionalBool struct {
+	value bool
+	set   bool
+}
+
+// Bool returns an OptionalBool explicitly set to v
+func Bool(v bool) OptionalBool {
+	return OptionalBool{value: v, set: true}
+}
+
+// IsSet reports whether the value was explicitly set
+func (b OptionalBool) IsSet() bool {
+	return b.set
+}
+
+// Value returns the boolean value, false if unset
+func (b OptionalBool) Value() bool {
+	return b.set && b.value
+}
+
+// Or returns the boolean value, or def if unset
+func (b OptionalBool) Or(def bool) bool {
+	if !b.set {
+		return def
+	}
+	return b.value
+}
+
+// IsZero reports whether the value is unset (used by the omitzero JSON option)
+func (b OptionalBool) IsZero() bool {
+	return !b.set
+}
+
+// MarshalJSON encodes an unset value as null
+func (b OptionalBool) MarshalJSON() ([]byte, error) {
+	if !b.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(b.value)
+}
+
+// UnmarshalJSON decodes true/false as set, null leaves the value unchanged
+func (b *OptionalBool) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = Bool(v)
+	return nil
+}
+
+var optionalBoolType = reflect.TypeOf(OptionalBool{})
+
+// Merge merges the values set in overlay over dst.
+// Unset booleans, empty strings, zero numbers and nil slices/maps in overlay
+// are treated as "not specified" and leave dst unchanged. An explicitly
+// empty list (e.g. "file_patterns": []) does replace the lower layer.
+// Configuration files are merged with MergeJSON, which can also set zero values.
+func Merge(dst *Config, overlay *Config) {
+	if dst == nil || overlay == nil {
+		return
+	}
+	mergeValue(reflect.ValueOf(dst).Elem(), reflect.ValueOf(overlay).Elem())
+}
+
+// MergeFocus merges the focus values set in overlay over dst
+func MergeFocus(dst *FocusConfig, overlay *FocusConfig) {
+	if dst == nil || overlay == nil {
+		return
+	}
+	mergeValue(reflect.ValueOf(dst).Elem(), reflect.ValueOf(overlay).Elem())
+}
+
+// mergeValue recursively merges src over dst
+func mergeValue(dst, src reflect.Value) {
+	if src.Type() == optionalBoolType {
+		if src.Interface().(OptionalBool).IsSet() {
+			dst.Set(src)
+		}
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if !dst.Field(i).CanSet() {
+				continue
+			}
+			mergeValue(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice, reflect.Map, reflect.Pointer:
+		if !src.IsNil() {
+			dst.Set(src)
+		}
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
+
+// MergeJSON merges the keys present in a JSON document over dst, a pointer to a
+// configuration struct. Unlike Merge, a key set to a zero value ("max_commits": 0,
+// "output_dir": "") overrides the lower layer, while null leaves it unchanged.
+// Objects are merged key by key, maps entry by entry, other values are replaced.
+func MergeJSON(dst any, data []byte) error {
+	return mergeJSONValue(reflect.ValueOf(dst).Elem(), data)
+}
+
+// mergeJSONValue merges a JSON value over dst
+func mergeJSONValue(dst reflect.Value, data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+
+	switch {
+	case dst.Kind() == reflect.Struct && dst.Type() != optionalBoolType:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		return mergeJSONFields(dst, fields)
+	case dst.Kind() == reflect.Map:
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+		merged := reflect.MakeMapWithSize(dst.Type(), dst.Len()+len(entries))
+		for iter := dst.MapRange(); iter.Next(); {
+			merged.SetMapIndex(iter.Key(), iter.Value())
+		}
+		for key, raw := range entries {
+			value := reflect.New(dst.Type().Elem())
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			merged.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), value.Elem())
+		}
+		dst.Set(merged)
+	default:
+		// Decoded into a new value, so slices of the lower layer are never written to
+		value := reflect.New(dst.Type())
+		if err := json.Unmarshal(data, value.Interface()); err != nil {
+			return err
+		}
+		dst.Set(value.Elem())
+	}
+	return nil
+}
+
+// mergeJSONFields merges the present fields of a JSON object over a struct, keys
+// are matched like encoding/json does, preferring an exact match
+func mergeJSONFields(dst reflect.Value, fields map[string]json.RawMessage) error {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := mergeJSONFields(dst.Field(i), fields); err != nil {
+					return err
+				}
+				continue
+			}
+			name = field.Name
+		}
+
+		raw, ok := fields[name]
+		if !ok {
+			for key, value := range fields {
+				if strings.EqualFold(key, name) {
+					raw, ok = value, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := mergeJSONValue(dst.Field(i), raw); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// isNull reports whether a JSON value is null
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
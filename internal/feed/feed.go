@@ -0,0 +1,479 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"warmy/internal/config"
+	wgit "warmy/internal/git"
+	"warmy/internal/logger"
+	"warmy/internal/output"
+	"warmy/internal/pipeline"
+	"warmy/internal/types"
+)
+
+// cveID matches a CVE id in a file path
+var cveID = regexp.MustCompile(`(?i)CVE-\d{4}-\d{4,}`)
+
+// Run walks the commit range of the analyzer configuration and writes a feed of
+// the CVE entries added or materially changed in the range.
+// Entries are focus changes of added or modified files, each CVE id is reported once
+// with its newest commit. On cancellation the feed of the analyzed commits is still written.
+func Run(ctx context.Context, analyzer *wgit.Analyzer) (*types.FeedSummary, error) {
+	cfg := analyzer.Config()
+	log := analyzer.Logger()
+
+	for _, format := range cfg.Feed.Formats {
+		switch format {
+		case config.FeedJSON, config.FeedAtom, config.FeedRSS:
+		default:
+			return nil, fmt.Errorf("invalid feed format %q, expected %s, %s or %s", format,
+				config.FeedJSON, config.FeedAtom, config.FeedRSS)
+		}
+	}
+
+	summary := &types.FeedSummary{
+		RepoPath:    cfg.RepoPath,
+		CommitRange: cfg.CommitRange,
+		AnalyzeTime: time.Now().Format("20060102-150405"),
+		Entries:     make([]types.FeedEntry, 0),
+	}
+
+	opts := pipeline.Options{
+		RepoPath:    cfg.RepoPath,
+		CommitRange: cfg.CommitRange,
+		MaxCommits:  cfg.MaxCommits,
+		Workers:     cfg.CommitWorkers,
+	}
+	if cfg.Progress.Value() {
+		opts.Progress = os.Stderr
+	}
+
+	// Results arrive newest first, so the first entry of a CVE id is its newest
+	seen := make(map[string]int)
+	err := pipeline.Execute(ctx, analyzer, opts, func(r pipeline.Result) error {
+		summary.TotalCommits = r.Total + r.Unlisted
+		summary.UnlistedSkipped = r.Unlisted
+		switch {
+		case r.Skipped != "":
+			summary.SkippedCommits++
+			return nil
+		case r.Err != nil:
+			summary.FailedCommits++
+			log.WithFields(logger.Fields{
+				"commit": r.Hash.String(),
+				"error":  r.Err.Error(),
+			}).Warn("Failed to analyze commit")
+			return nil
+		}
+
+		for _, entry := range commitEntries(r.CommitInfo, cfg.Feed.MaterialChanges) {
+			i, ok := seen[entry.CVEID]
+			if !ok {
+				seen[entry.CVEID] = len(summary.Entries)
+				summary.Entries = append(summary.Entries, entry)
+				continue
+			}
+			// A CVE entry added in the range stays added when later commits change it
+			if entry.Kind == types.FeedAdded {
+				summary.Entries[i].Kind = types.FeedAdded
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			return nil, err
+		}
+		summary.Canceled = true
+	}
+	summary.SkippedCommits += summary.UnlistedSkipped
+
+	if !cfg.NoFile.Value() {
+		for _, format := range cfg.Feed.Formats {
+			fullPath, err := writeFeed(cfg, format, summary.Entries)
+			if err != nil {
+				return summary, err
+			}
+			summary.OutputFiles = append(summary.OutputFiles, fullPath)
+
+			log.WithFields(logger.Fields{
+				"format":   format,
+				"filepath": fullPath,
+			}).Info("Feed saved to file")
+		}
+	}
+
+	log.WithFields(logger.Fields{
+		"total_commits":  summary.TotalCommits,
+		"failed_commits": summary.FailedCommits,
+		"entries":        len(summary.Entries),
+	}).Info("Feed generation completed")
+
+	return summary, nil
+}
+
+// commitEntries returns the CVE entries of the focus changes of a commit. Added files
+// are added entries, modified files are changed entries if their template has one
+// of the material changes, or if they are no template.
+func commitEntries(commitInfo *types.CommitInfo, materialChanges []string) []types.FeedEntry {
+	var entries []types.FeedEntry
+	seen := make(map[string]bool)
+
+	for _, change := range commitInfo.Changes {
+		if !change.IsFocus {
+			continue
+		}
+
+		entry := types.FeedEntry{
+			File:      change.Filepath,
+			Commit:    commitInfo.Hash,
+			ShortHash: commitInfo.ShortHash,
+			Author:    commitInfo.Author.Name,
+			Email:     commitInfo.Author.Email,
+			Date:      time.Unix(commitInfo.Timestamp, 0).UTC().Format(time.RFC3339),
+		}
+		switch change.Action {
+		case "add":
+			entry.Kind = types.FeedAdded
+		case "modify", "rename":
+			entry.Kind = types.FeedChanged
+			if change.Template != nil && !hasAny(change.Template.Changes, materialChanges) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		ids := cveID.FindAllString(change.Filepath, -1)
+		if template := change.Template; template != nil {
+			entry.Severity = template.Template.Severity
+			entry.Name = template.Template.Name
+			entry.Changes = template.Changes
+			if len(template.Template.CVEIDs) > 0 {
+				ids = template.Template.CVEIDs
+			}
+		}
+
+		for _, id := range ids {
+			id = strings.ToUpper(id)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			entry.CVEID = id
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// hasAny reports whether any item of list is in items
+func hasAny(list, items []string) bool {
+	for _, item := range list {
+		if types.Contains(items, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFeed writes the entries in a feed format to the output directory and
+// returns the full file path
+func writeFeed(cfg *config.Config, format string, entries []types.FeedEntry) (string, error) {
+	var filename string
+	var data []byte
+	var err error
+
+	switch format {
+	case config.FeedJSON:
+		filename = "feed.json"
+		data, err = jsonFeed(cfg, entries)
+	case config.FeedAtom:
+		filename = "feed.atom"
+		data, err = atomFeed(cfg, entries)
+	case config.FeedRSS:
+		filename = "feed.rss"
+		data, err = rssFeed(cfg, entries)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to format %s feed: %w", format, err)
+	}
+
+	return output.SaveToFile(cfg.OutputDir, filename, string(data))
+}
+
+// entryTitle returns the title of a feed entry, e.g. "CVE-2021-1234 added (high)"
+func entryTitle(entry types.FeedEntry) string {
+	title := fmt.Sprintf("%s %s", entry.CVEID, entry.Kind)
+	if entry.Severity != "" {
+		title += fmt.Sprintf(" (%s)", entry.Severity)
+	}
+	return title
+}
+
+// entryText returns the text of a feed entry
+func entryText(entry types.FeedEntry) string {
+	var b strings.Builder
+	if entry.Name != "" {
+		b.WriteString(entry.Name + "\n")
+	}
+	b.WriteString(fmt.Sprintf("File: %s\nCommit: %s\nAuthor: %s", entry.File, entry.Commit, entry.Author))
+	if len(entry.Changes) > 0 {
+		b.WriteString("\nChanges: " + strings.Join(entry.Changes, ", "))
+	}
+	return b.String()
+}
+
+// feedID returns the id of a feed without link, a URN derived from the repository
+// path as paths may hold characters that are not valid in a URI
+func feedID(repoPath string) string {
+	sum := sha256.Sum256([]byte(repoPath))
+	return "urn:warmy:feed:" + hex.EncodeToString(sum[:16])
+}
+
+// entryID returns the unique id of a feed entry, a new commit changing a CVE entry
+// gives a new feed entry
+func entryID(entry types.FeedEntry) string {
+	return fmt.Sprintf("urn:warmy:%s:%s", strings.ToLower(entry.CVEID), entry.Commit)
+}
+
+// commitURL returns the URL of the commit of an entry, empty if not configured
+func commitURL(cfg *config.Config, entry types.FeedEntry) string {
+	if cfg.Feed.CommitURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(cfg.Feed.CommitURL, "{hash}", entry.Commit)
+}
+
+// updated returns the date of the newest entry, the current time for an empty feed
+func updated(entries []types.FeedEntry) string {
+	newest := ""
+	for _, entry := range entries {
+		if entry.Date > newest {
+			newest = entry.Date
+		}
+	}
+	if newest == "" {
+		return time.Now().UTC().Format(time.RFC3339)
+	}
+	return newest
+}
+
+// jsonFeedDocument JSON Feed 1.1 document
+type jsonFeedDocument struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonFeedItem JSON Feed item, the CVE entry is kept in the _warmy extension
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Tags          []string         `json:"tags,omitempty"`
+	Warmy         types.FeedEntry  `json:"_warmy"`
+}
+
+// jsonFeedAuthor JSON Feed author
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonFeed formats entries as JSON Feed 1.1
+func jsonFeed(cfg *config.Config, entries []types.FeedEntry) ([]byte, error) {
+	doc := jsonFeedDocument{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       cfg.Feed.Title,
+		HomePageURL: cfg.Feed.Link,
+		Items:       make([]jsonFeedItem, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		item := jsonFeedItem{
+			ID:            entryID(entry),
+			URL:           commitURL(cfg, entry),
+			Title:         entryTitle(entry),
+			ContentText:   entryText(entry),
+			DatePublished: entry.Date,
+			Authors:       []jsonFeedAuthor{{Name: entry.Author}},
+			Warmy:         entry,
+		}
+		if entry.Severity != "" {
+			item.Tags = []string{entry.Severity}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	if cfg.PrettyJSON.Value() {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return json.Marshal(doc)
+}
+
+// atomDocument Atom 1.0 feed
+type atomDocument struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomEntry Atom entry
+type atomEntry struct {
+	Title    string        `xml:"title"`
+	ID       string        `xml:"id"`
+	Updated  string        `xml:"updated"`
+	Author   atomAuthor    `xml:"author"`
+	Link     *atomLink     `xml:"link,omitempty"`
+	Category *atomCategory `xml:"category,omitempty"`
+	Summary  string        `xml:"summary"`
+}
+
+// atomLink Atom link
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// atomAuthor Atom author
+type atomAuthor struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+// atomCategory Atom category
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomFeed formats entries as Atom 1.0
+func atomFeed(cfg *config.Config, entries []types.FeedEntry) ([]byte, error) {
+	doc := atomDocument{
+		Title:   cfg.Feed.Title,
+		ID:      feedID(cfg.RepoPath),
+		Updated: updated(entries),
+	}
+	if cfg.Feed.Link != "" {
+		doc.ID = cfg.Feed.Link
+		doc.Link = &atomLink{Href: cfg.Feed.Link}
+	}
+	for _, entry := range entries {
+		atom := atomEntry{
+			Title:   entryTitle(entry),
+			ID:      entryID(entry),
+			Updated: entry.Date,
+			Author:  atomAuthor{Name: entry.Author, Email: entry.Email},
+			Summary: entryText(entry),
+		}
+		if url := commitURL(cfg, entry); url != "" {
+			atom.Link = &atomLink{Href: url}
+		}
+		if entry.Severity != "" {
+			atom.Category = &atomCategory{Term: entry.Severity}
+		}
+		doc.Entries = append(doc.Entries, atom)
+	}
+	return marshalXML(doc)
+}
+
+// rssDocument RSS 2.0 feed
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssChannel RSS channel
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem RSS item
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	Author      string  `xml:"author,omitempty"` // Email address, optionally followed by the name in parentheses
+	Category    string  `xml:"category,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// rssGUID RSS item guid, entry ids are no URLs
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssAuthor returns the RSS author of an entry, empty without author email
+func rssAuthor(entry types.FeedEntry) string {
+	if entry.Email == "" {
+		return ""
+	}
+	if entry.Author == "" {
+		return entry.Email
+	}
+	return fmt.Sprintf("%s (%s)", entry.Email, entry.Author)
+}
+
+// rssFeed formats entries as RSS 2.0
+func rssFeed(cfg *config.Config, entries []types.FeedEntry) ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         cfg.Feed.Title,
+			Link:          cfg.Feed.Link,
+			Description:   fmt.Sprintf("CVE entries added or changed in %s", cfg.RepoPath),
+			LastBuildDate: rssDate(updated(entries)),
+		},
+	}
+	for _, entry := range entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       entryTitle(entry),
+			Link:        commitURL(cfg, entry),
+			Description: entryText(entry),
+			Author:      rssAuthor(entry),
+			Category:    entry.Severity,
+			GUID:        rssGUID{Value: entryID(entry)},
+			PubDate:     rssDate(entry.Date),
+		})
+	}
+	return marshalXML(doc)
+}
+
+// rssDate converts an RFC 3339 date to the RFC 1123 date of RSS
+func rssDate(date string) string {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// marshalXML marshals an indented XML document with XML declaration
+func marshalXML(doc any) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
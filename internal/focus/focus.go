@@ -3,39 +3,73 @@ package focus
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"warmy/internal/config"
 	"warmy/internal/logger"
 	"warmy/internal/types"
 )
 
+// Message rule fields
+const (
+	FieldMessage     = "message"      // Commit message subject
+	FieldDescription = "description"  // Commit message body
+	FieldFullMessage = "full_message" // Full commit message
+	FieldType        = "type"         // Conventional Commits type
+	FieldScope       = "scope"        // Conventional Commits scope
+	FieldBreaking    = "breaking"     // Conventional Commits breaking change description
+	FieldTrailers    = "trailers"     // Trailers, one "Key: value" line each
+)
+
+// Message rule actions
+const (
+	ActionFocus    = "focus"    // Mark the commit as focus
+	ActionSuppress = "suppress" // Drop the focus of the files of the commit
+)
+
 // CompiledPatterns compiled regular expressions
 type CompiledPatterns struct {
-	FilePatterns   []*regexp.Regexp
-	IgnorePatterns []*regexp.Regexp
+	FilePatterns     []*regexp.Regexp
+	IgnorePatterns   []*regexp.Regexp
+	MessageRules     []CompiledMessageRule
+	TrustedAuthors   []*regexp.Regexp
+	UntrustedAuthors []*regexp.Regexp
 }
 
-var (
-	compiledPatterns *CompiledPatterns
-	log              logger.Logger
-)
+// CompiledMessageRule compiled message rule with its defaults applied
+type CompiledMessageRule struct {
+	Name    string
+	Field   string
+	Action  string
+	Pattern *regexp.Regexp
+}
 
-// Init initializes focus feature
-func Init() error {
-	cfg := config.GetConfig()
-	if !cfg.Focus.Enable {
-		return nil
-	}
+// Engine focus checker built from a focus configuration.
+// An Engine is immutable after creation and safe for concurrent use.
+type Engine struct {
+	cfg      config.FocusConfig
+	patterns *CompiledPatterns
+	log      logger.Logger
+}
 
-	log = logger.GetLogger()
+// New creates focus engine from focus configuration
+func New(focusConfig config.FocusConfig, log logger.Logger) (*Engine, error) {
+	engine := &Engine{
+		cfg:      focusConfig,
+		patterns: &CompiledPatterns{},
+		log:      log,
+	}
+	if !focusConfig.Enable.Value() {
+		return engine, nil
+	}
 
 	var err error
-	compiledPatterns, err = compilePatterns(&cfg.Focus)
+	engine.patterns, err = compilePatterns(&focusConfig)
 	if err != nil {
-		return fmt.Errorf("failed to compile regular expressions: %w", err)
+		return nil, fmt.Errorf("failed to compile regular expressions: %w", err)
 	}
 
-	return nil
+	return engine, nil
 }
 
 // compilePatterns compiles regular expressions
@@ -63,24 +97,261 @@ func compilePatterns(focusConfig *config.FocusConfig) (*CompiledPatterns, error)
 		compiled.IgnorePatterns = append(compiled.IgnorePatterns, re)
 	}
 
+	// Compile author trust lists
+	for _, list := range []struct {
+		patterns []string
+		target   *[]*regexp.Regexp
+		name     string
+	}{
+		{focusConfig.TrustedAuthors, &compiled.TrustedAuthors, "focus.trusted_authors"},
+		{focusConfig.UntrustedAuthors, &compiled.UntrustedAuthors, "focus.untrusted_authors"},
+	} {
+		patterns, err := types.CompileRegexps(list.name, list.patterns)
+		if err != nil {
+			return nil, err
+		}
+		*list.target = patterns
+	}
+
+	// Check escalated signature statuses
+	for _, status := range focusConfig.EscalateSignatures {
+		switch status {
+		case types.SignatureUnsigned, types.SignatureBad, types.SignatureUnknownKey, types.SignatureUnverified:
+		default:
+			return nil, fmt.Errorf("invalid escalated signature status: %s", status)
+		}
+	}
+
+	// Check severity change directions
+	for _, direction := range focusConfig.SeverityChanges {
+		switch direction {
+		case types.SeverityEscalation, types.SeverityDeescalation, types.SeverityChanged:
+		default:
+			return nil, fmt.Errorf("invalid severity change direction: %s", direction)
+		}
+	}
+
+	// Compile message rules
+	for _, rule := range focusConfig.MessageRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile message rule pattern: %s, error: %v", rule.Pattern, err)
+		}
+
+		compiledRule := CompiledMessageRule{Name: rule.Name, Field: rule.Field, Action: rule.Action, Pattern: re}
+		if compiledRule.Name == "" {
+			compiledRule.Name = rule.Pattern
+		}
+		switch compiledRule.Field {
+		case "":
+			compiledRule.Field = FieldFullMessage
+		case FieldMessage, FieldDescription, FieldFullMessage, FieldType, FieldScope, FieldBreaking, FieldTrailers:
+		default:
+			return nil, fmt.Errorf("invalid message rule field: %s", rule.Field)
+		}
+		switch compiledRule.Action {
+		case "":
+			compiledRule.Action = ActionFocus
+		case ActionFocus, ActionSuppress:
+		default:
+			return nil, fmt.Errorf("invalid message rule action: %s", rule.Action)
+		}
+		compiled.MessageRules = append(compiled.MessageRules, compiledRule)
+	}
+
 	return compiled, nil
 }
 
+// CheckMessage checks the commit message against the message rules. It returns the
+// matching rules, whether the commit is focus and whether the focus of its files is
+// suppressed.
+func (e *Engine) CheckMessage(message *types.CommitMessage) ([]types.MessageFocusInfo, bool, bool) {
+	if !e.cfg.Enable.Value() || len(e.patterns.MessageRules) == 0 {
+		return nil, false, false
+	}
+
+	fields := map[string]string{
+		FieldMessage:     message.Subject,
+		FieldDescription: message.Description,
+		FieldFullMessage: message.FullMessage,
+	}
+	if c := message.Conventional; c != nil {
+		fields[FieldType] = c.Type
+		fields[FieldScope] = c.Scope
+		if c.Breaking {
+			fields[FieldBreaking] = c.BreakingChange
+		}
+	}
+	if len(message.Trailers) > 0 {
+		lines := make([]string, 0, len(message.Trailers))
+		for _, trailer := range message.Trailers {
+			lines = append(lines, trailer.Key+": "+trailer.Value)
+		}
+		fields[FieldTrailers] = strings.Join(lines, "\n")
+	}
+
+	var matches []types.MessageFocusInfo
+	isFocus, suppress := false, false
+	for _, rule := range e.patterns.MessageRules {
+		// Type, scope and breaking rules never match commits without a Conventional
+		// Commits header, trailer rules never match commits without trailers
+		value, ok := fields[rule.Field]
+		if !ok {
+			continue
+		}
+		loc := rule.Pattern.FindStringIndex(value)
+		if loc == nil {
+			continue
+		}
+		match := value[loc[0]:loc[1]]
+
+		matches = append(matches, types.MessageFocusInfo{
+			Rule:   rule.Name,
+			Field:  rule.Field,
+			Action: rule.Action,
+			Match:  types.TruncateString(match, 100),
+		})
+		if rule.Action == ActionSuppress {
+			suppress = true
+		} else {
+			isFocus = true
+		}
+
+		e.log.WithFields(logger.Fields{
+			"rule":   rule.Name,
+			"field":  rule.Field,
+			"action": rule.Action,
+		}).Debug("Commit message matches message rule")
+	}
+
+	return matches, isFocus, suppress
+}
+
+// DetectsNewContributors reports whether first commits of authors need to be detected
+func (e *Engine) DetectsNewContributors() bool {
+	return e.cfg.Enable.Value() && e.cfg.NewContributors.Value()
+}
+
+// CheckAuthor returns the trust level of a commit author, empty if no trust list applies.
+// Untrusted authors take precedence over trusted authors, and trusted authors over
+// new contributors, so automation accounts stay low severity on their first commit.
+func (e *Engine) CheckAuthor(name, email string, newContributor bool) string {
+	if !e.cfg.Enable.Value() {
+		return ""
+	}
+
+	matches := func(patterns []*regexp.Regexp) bool {
+		for _, pattern := range patterns {
+			if pattern.MatchString(name) || pattern.MatchString(email) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case matches(e.patterns.UntrustedAuthors):
+		return types.TrustUntrusted
+	case matches(e.patterns.TrustedAuthors):
+		return types.TrustTrusted
+	case newContributor && e.cfg.NewContributors.Value():
+		return types.TrustNewContributor
+	}
+	return ""
+}
+
+// ApplyAuthorTrust sets the severity of a focus file from the author trust level and
+// records the trust level in the focus reason. Without trust level the severity is medium.
+func (e *Engine) ApplyAuthorTrust(change *types.ChangeInfo, focusFile *types.FocusFileInfo, trust string) {
+	focusFile.Severity = types.SeverityMedium
+
+	var note string
+	switch trust {
+	case types.TrustTrusted:
+		focusFile.Severity, note = types.SeverityLow, "trusted author"
+	case types.TrustUntrusted:
+		focusFile.Severity, note = types.SeverityHigh, "untrusted author"
+	case types.TrustNewContributor:
+		focusFile.Severity, note = types.SeverityHigh, "new contributor"
+	default:
+		return
+	}
+
+	focusFile.Reason = fmt.Sprintf("%s (%s)", focusFile.Reason, note)
+	change.FocusReason = focusFile.Reason
+}
+
+// ApplySignature raises the severity of a focus file to high if the signature status of
+// its commit is escalated, and records the status in the focus reason
+func (e *Engine) ApplySignature(change *types.ChangeInfo, focusFile *types.FocusFileInfo, signature *types.SignatureInfo) {
+	status := types.SignatureUnsigned
+	if signature != nil {
+		status = signature.Status
+	}
+	if !types.Contains(e.cfg.EscalateSignatures, status) {
+		return
+	}
+
+	var note string
+	switch status {
+	case types.SignatureUnsigned:
+		note = "unsigned commit"
+	case types.SignatureBad:
+		note = "bad signature"
+	case types.SignatureUnknownKey:
+		note = "signed by unknown key"
+	case types.SignatureUnverified:
+		note = "unverified signature"
+	}
+
+	focusFile.Severity = types.SeverityHigh
+	focusFile.Reason = fmt.Sprintf("%s (%s)", focusFile.Reason, note)
+	change.FocusReason = focusFile.Reason
+}
+
+// ApplySeverityChange raises the severity of a focus file to high if the change
+// escalates the tracked severity field
+func (e *Engine) ApplySeverityChange(change *types.ChangeInfo, focusFile *types.FocusFileInfo) {
+	if change.Severity != nil && change.Severity.Direction == types.SeverityEscalation {
+		focusFile.Severity = types.SeverityHigh
+	}
+}
+
 // CheckFocusChange checks if a change should be marked as focus
-func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
-	cfg := config.GetConfig()
-	if !cfg.Focus.Enable {
+func (e *Engine) CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
+	if !e.cfg.Enable.Value() {
 		return nil, false
 	}
 
 	// Check if file is in ignore list (file path)
-	if isIgnoredByFilePatterns(change.Filepath, compiledPatterns.IgnorePatterns) {
+	if e.isIgnoredByFilePatterns(change.Filepath, e.patterns.IgnorePatterns) {
+		return nil, false
+	}
+
+	// Check gitattributes of the file
+	if !e.matchesAttributeRules(change) {
 		return nil, false
 	}
 
+	// Severity changes of the configured directions are focus whatever the file patterns
+	if sc := change.Severity; sc != nil && types.Contains(e.cfg.SeverityChanges, sc.Direction) {
+		reason := fmt.Sprintf("Severity %s from %s to %s", sc.Direction, sc.OldSeverity, sc.NewSeverity)
+		change.IsFocus = true
+		change.FocusReason = reason
+
+		e.log.WithFields(logger.Fields{
+			"file":      change.Filepath,
+			"action":    change.Action,
+			"direction": sc.Direction,
+			"reason":    reason,
+		}).Debug("Severity change marked as focus")
+
+		return &types.FocusFileInfo{Filepath: change.Filepath, Action: change.Action, Reason: reason}, true
+	}
+
 	// First step: check if file is yaml, yml, or json
 	isTargetFile := false
-	for _, pattern := range compiledPatterns.FilePatterns {
+	for _, pattern := range e.patterns.FilePatterns {
 		if pattern.MatchString(change.Filepath) {
 			isTargetFile = true
 			break
@@ -96,13 +367,23 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 		Action:   change.Action,
 	}
 
+	// Check for mode, symlink and submodule changes
+	switch change.Kind {
+	case types.KindSymlink, types.KindSubmodule:
+		return e.checkKindChange(change, focusFile)
+	case types.KindModeChange:
+		if focus, ok := e.checkKindChange(change, focusFile); ok {
+			return focus, ok
+		}
+	}
+
 	// Check for new files
-	if cfg.Focus.AddFiles && change.Action == "add" {
+	if e.cfg.AddFiles.Value() && change.Action == "add" {
 		change.IsFocus = true
 		change.FocusReason = "New file"
 		focusFile.Reason = "New file"
 
-		log.WithFields(logger.Fields{
+		e.log.WithFields(logger.Fields{
 			"file":   change.Filepath,
 			"action": change.Action,
 			"reason": change.FocusReason,
@@ -112,12 +393,12 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 	}
 
 	// Check for deleted files
-	if cfg.Focus.DeleteFiles && change.Action == "delete" {
+	if e.cfg.DeleteFiles.Value() && change.Action == "delete" {
 		change.IsFocus = true
 		change.FocusReason = "Deleted file"
 		focusFile.Reason = "Deleted file"
 
-		log.WithFields(logger.Fields{
+		e.log.WithFields(logger.Fields{
 			"file":   change.Filepath,
 			"action": change.Action,
 			"reason": change.FocusReason,
@@ -127,14 +408,14 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 	}
 
 	// Check for modified file content
-	if cfg.Focus.ModifyFiles && change.Action == "modify" {
+	if e.cfg.ModifyFiles.Value() && change.Action == "modify" {
 		matchedLines := make([]string, 0)
 		matchCount := 0
 
 		// Check added content: if a line doesn't match ignore patterns, mark as focus
 		for _, line := range change.AdditionsList {
 			// Check if this line doesn't contain any ignore patterns
-			if !isLineIgnored(line.Content, compiledPatterns.IgnorePatterns) {
+			if !isLineIgnored(line.Content, e.patterns.IgnorePatterns) {
 				matchCount++
 				// Only save summary of matched line (first 100 characters)
 				lineSummary := types.TruncateString(line.Content, 100)
@@ -147,7 +428,7 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 		// Check removed content: if a line doesn't match ignore patterns, mark as focus
 		for _, line := range change.DeletionsList {
 			// Check if this line doesn't contain any ignore patterns
-			if !isLineIgnored(line.Content, compiledPatterns.IgnorePatterns) {
+			if !isLineIgnored(line.Content, e.patterns.IgnorePatterns) {
 				matchCount++
 				// Only save summary of matched line (first 100 characters)
 				lineSummary := types.TruncateString(line.Content, 100)
@@ -164,7 +445,7 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 			focusFile.MatchCount = matchCount
 			focusFile.MatchLines = matchedLines
 
-			log.WithFields(logger.Fields{
+			e.log.WithFields(logger.Fields{
 				"file":        change.Filepath,
 				"action":      change.Action,
 				"match_count": matchCount,
@@ -178,11 +459,65 @@ func CheckFocusChange(change *types.ChangeInfo) (*types.FocusFileInfo, bool) {
 	return nil, false
 }
 
+// checkKindChange checks mode, symlink and submodule changes against the option of their kind.
+// A disabled symlink or submodule change is never focus, while a disabled mode change is
+// still checked like any other change of its action by the caller.
+func (e *Engine) checkKindChange(change *types.ChangeInfo, focusFile *types.FocusFileInfo) (*types.FocusFileInfo, bool) {
+	var enabled bool
+	var reason string
+
+	switch change.Kind {
+	case types.KindModeChange:
+		enabled = e.cfg.ModeChanges.Value()
+		reason = fmt.Sprintf("File mode changed from %s to %s", change.OldMode, change.NewMode)
+	case types.KindSymlink:
+		enabled = e.cfg.SymlinkChanges.Value()
+		reason = kindReason("Symlink", change.Action)
+	case types.KindSubmodule:
+		enabled = e.cfg.SubmoduleChanges.Value()
+		reason = kindReason("Submodule", change.Action)
+		if change.Submodule != nil && change.Submodule.OldCommit != "" && change.Submodule.NewCommit != "" {
+			reason = fmt.Sprintf("Submodule commit changed from %s to %s",
+				change.Submodule.OldCommit[:8], change.Submodule.NewCommit[:8])
+		}
+	}
+
+	if !enabled {
+		return nil, false
+	}
+
+	change.IsFocus = true
+	change.FocusReason = reason
+	focusFile.Reason = reason
+
+	e.log.WithFields(logger.Fields{
+		"file":   change.Filepath,
+		"action": change.Action,
+		"kind":   change.Kind,
+		"reason": change.FocusReason,
+	}).Debug("Special change marked as focus")
+
+	return focusFile, true
+}
+
+// kindReason returns the focus reason of a symlink or submodule change by action
+func kindReason(kind, action string) string {
+	switch action {
+	case "add":
+		return kind + " added"
+	case "delete":
+		return kind + " removed"
+	case "rename":
+		return kind + " renamed"
+	}
+	return kind + " changed"
+}
+
 // isIgnoredByFilePatterns checks if file matches ignore patterns
-func isIgnoredByFilePatterns(filepath string, patterns []*regexp.Regexp) bool {
+func (e *Engine) isIgnoredByFilePatterns(filepath string, patterns []*regexp.Regexp) bool {
 	for _, pattern := range patterns {
 		if pattern.MatchString(filepath) {
-			log.WithFields(logger.Fields{
+			e.log.WithFields(logger.Fields{
 				"file":    filepath,
 				"pattern": pattern.String(),
 			}).Debug("File matches ignore pattern")
@@ -192,6 +527,38 @@ func isIgnoredByFilePatterns(filepath string, patterns []*regexp.Regexp) bool {
 	return false
 }
 
+// matchesAttributeRules checks file gitattributes against ignore and require attribute rules
+func (e *Engine) matchesAttributeRules(change *types.ChangeInfo) bool {
+	for _, rule := range e.cfg.IgnoreAttributes {
+		if matchesAttribute(change.Attributes, rule) {
+			e.log.WithFields(logger.Fields{
+				"file":      change.Filepath,
+				"attribute": rule,
+			}).Debug("File has ignored attribute")
+			return false
+		}
+	}
+	for _, rule := range e.cfg.RequireAttributes {
+		if !matchesAttribute(change.Attributes, rule) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAttribute checks an attribute rule: "name" matches a set attribute,
+// "-name" an unset attribute and "name=value" an attribute with that value
+func matchesAttribute(attrs map[string]string, rule string) bool {
+	if name, ok := strings.CutPrefix(rule, "-"); ok {
+		return attrs[name] == "false"
+	}
+	if name, value, ok := strings.Cut(rule, "="); ok {
+		return attrs[name] == value
+	}
+	value, ok := attrs[rule]
+	return ok && value != "false"
+}
+
 // isLineIgnored checks if line content matches ignore patterns
 func isLineIgnored(content string, patterns []*regexp.Regexp) bool {
 	for _, pattern := range patterns {
@@ -0,0 +1,197 @@
+package git
+
+import (
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// attributesFile name of the files holding gitattributes rules
+const attributesFile = ".gitattributes"
+
+// builtinMacros macro attributes defined by git itself
+var builtinMacros = map[string]string{
+	"binary": "-diff -merge -text",
+}
+
+// attributeResolver resolves gitattributes of paths from the .gitattributes
+// files of a commit tree. Only the files in the directories leading to a path
+// are read, and each directory is read once.
+// A resolver is not safe for concurrent use.
+type attributeResolver struct {
+	tree   *object.Tree
+	macros map[string][]gitattributes.Attribute          // Macro attributes, defined in the root .gitattributes only
+	dirs   map[string][]gitattributes.MatchAttribute     // Rules by directory, in file order
+	loaded bool                                          // Whether the root .gitattributes has been read
+	cache  map[string]map[string]gitattributes.Attribute // Resolved attributes by path
+}
+
+// newAttributeResolver creates a resolver for the tree, a nil tree has no attributes
+func newAttributeResolver(tree *object.Tree) *attributeResolver {
+	return &attributeResolver{
+		tree:   tree,
+		macros: make(map[string][]gitattributes.Attribute),
+		dirs:   make(map[string][]gitattributes.MatchAttribute),
+		cache:  make(map[string]map[string]gitattributes.Attribute),
+	}
+}
+
+// attributes returns the attributes of a file path, with macros expanded.
+// Rules of deeper directories and later lines take precedence, like in git.
+func (r *attributeResolver) attributes(filePath string) map[string]gitattributes.Attribute {
+	if attrs, ok := r.cache[filePath]; ok {
+		return attrs
+	}
+
+	attrs := make(map[string]gitattributes.Attribute)
+	if r.tree == nil {
+		r.cache[filePath] = attrs
+		return attrs
+	}
+	r.loadRoot()
+
+	// Attributes of the matching rules, lowest precedence first
+	var matched []gitattributes.Attribute
+	parts := strings.Split(filePath, "/")
+	for depth := 0; depth < len(parts); depth++ {
+		dir := strings.Join(parts[:depth], "/")
+		for _, rule := range r.rules(dir) {
+			if rule.Pattern == nil || !rule.Pattern.Match(parts) {
+				continue
+			}
+			matched = append(matched, rule.Attributes...)
+		}
+	}
+
+	// Like git, attributes are filled highest precedence first and the first state
+	// of an attribute wins, so unsetting a macro (-binary) also drops the expansion
+	// of a lower precedence rule setting it
+	for i := len(matched) - 1; i >= 0; i-- {
+		r.fill(attrs, matched[i])
+	}
+
+	r.cache[filePath] = attrs
+	return attrs
+}
+
+// fill records an attribute unless a rule of higher precedence already did, a
+// macro that is set is expanded at the precedence of the attribute setting it
+func (r *attributeResolver) fill(attrs map[string]gitattributes.Attribute, attr gitattributes.Attribute) {
+	if _, ok := attrs[attr.Name()]; ok {
+		return
+	}
+	attrs[attr.Name()] = attr
+
+	if attr.IsSet() {
+		macro := r.macros[attr.Name()]
+		for i := len(macro) - 1; i >= 0; i-- {
+			r.fill(attrs, macro[i])
+		}
+	}
+}
+
+// loadRoot reads macros and rules of the root .gitattributes
+func (r *attributeResolver) loadRoot() {
+	if r.loaded {
+		return
+	}
+	r.loaded = true
+
+	for name, definition := range builtinMacros {
+		macro, err := gitattributes.ParseAttributesLine("[attr]"+name+" "+definition, nil, true)
+		if err == nil {
+			r.macros[name] = macro.Attributes
+		}
+	}
+
+	rules := r.readRules("", true)
+	kept := rules[:0]
+	for _, rule := range rules {
+		if rule.Pattern == nil {
+			r.macros[rule.Name] = rule.Attributes
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	r.dirs[""] = kept
+}
+
+// rules returns the rules of the .gitattributes file of a directory
+func (r *attributeResolver) rules(dir string) []gitattributes.MatchAttribute {
+	if rules, ok := r.dirs[dir]; ok {
+		return rules
+	}
+	rules := r.readRules(dir, false)
+	r.dirs[dir] = rules
+	return rules
+}
+
+// readRules reads and parses the .gitattributes file of a directory.
+// Missing or unreadable files, and invalid lines, are ignored like git does.
+func (r *attributeResolver) readRules(dir string, allowMacro bool) []gitattributes.MatchAttribute {
+	file, err := r.tree.File(path.Join(dir, attributesFile))
+	if err != nil {
+		return nil
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil
+	}
+
+	var domain []string
+	if dir != "" {
+		domain = strings.Split(dir, "/")
+	}
+
+	var rules []gitattributes.MatchAttribute
+	for _, line := range strings.Split(content, "\n") {
+		rule, err := gitattributes.ParseAttributesLine(line, domain, allowMacro)
+		if err != nil || rule.Name == "" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// diffAttribute returns whether the attributes force a path to be diffed as
+// binary (-diff, binary) or as text (diff), ok is false if neither applies
+func diffAttribute(attrs map[string]gitattributes.Attribute) (binary bool, ok bool) {
+	attr, found := attrs["diff"]
+	if !found {
+		return false, false
+	}
+	switch {
+	case attr.IsUnset():
+		return true, true
+	case attr.IsSet():
+		return false, true
+	}
+	return false, false
+}
+
+// attributeValues converts attributes to report values: "true" for set,
+// "false" for unset, or the assigned value. Unspecified attributes are left out.
+func attributeValues(attrs map[string]gitattributes.Attribute) map[string]string {
+	var values map[string]string
+	for name, attr := range attrs {
+		var value string
+		switch {
+		case attr.IsValueSet():
+			value = attr.Value()
+		case attr.IsSet():
+			value = "true"
+		case attr.IsUnset():
+			value = "false"
+		default:
+			continue
+		}
+		if values == nil {
+			values = make(map[string]string, len(attrs))
+		}
+		values[name] = value
+	}
+	return values
+}
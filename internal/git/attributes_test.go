@@ -0,0 +1,138 @@
+package git
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// storeTree stores a tree of files by slash separated path and returns its hash
+func storeTree(t *testing.T, repo *git.Repository, files map[string]string) plumbing.Hash {
+	t.Helper()
+
+	subdirs := make(map[string]map[string]string)
+	tree := &object.Tree{}
+	for name, content := range files {
+		if dir, rest, found := strings.Cut(name, "/"); found {
+			if subdirs[dir] == nil {
+				subdirs[dir] = make(map[string]string)
+			}
+			subdirs[dir][rest] = content
+			continue
+		}
+
+		blob := repo.Storer.NewEncodedObject()
+		blob.SetType(plumbing.BlobObject)
+		w, err := blob.Writer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		hash, err := repo.Storer.SetEncodedObject(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: hash})
+	}
+	for dir, dirFiles := range subdirs {
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: storeTree(t, repo, dirFiles)})
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool { return tree.Entries[i].Name < tree.Entries[j].Name })
+
+	return storeObject(t, repo, tree.Encode)
+}
+
+func TestAttributes(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.TreeObject(storeTree(t, repo, map[string]string{
+		".gitattributes": strings.Join([]string{
+			"[attr]generated linguist-generated diff=generated -text",
+			"*.dat binary",
+			"*.bin binary",
+			"*.bin -binary",
+			"*.raw binary",
+			"*.raw !binary",
+			"*.txt -text",
+			"*.txt binary",
+			"*.txt -binary",
+			"*.txt diff",
+			"*.gen generated",
+			"*.gen text",
+			"*.keep binary",
+			"*.keep diff",
+			"*.line binary -binary",
+			"*.rev -binary binary",
+		}, "\n"),
+		"sub/.gitattributes": "*.dat -binary\n[attr]ignored -diff\n*.ign ignored\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path       string
+		want       map[string]string
+		wantForced bool // Whether the diff attribute applies
+		wantBinary bool
+	}{
+		{path: "a.go", want: nil},
+		{
+			path:       "a.dat",
+			want:       map[string]string{"binary": "true", "diff": "false", "merge": "false", "text": "false"},
+			wantForced: true,
+			wantBinary: true,
+		},
+		{path: "sub/a.dat", want: map[string]string{"binary": "false"}},
+		{path: "a.bin", want: map[string]string{"binary": "false"}},
+		{path: "a.raw", want: nil},
+		{path: "a.txt", want: map[string]string{"binary": "false", "diff": "true", "text": "false"}, wantForced: true},
+		{
+			path: "a.gen",
+			want: map[string]string{"generated": "true", "linguist-generated": "true", "diff": "generated", "text": "true"},
+		},
+		{
+			path:       "a.keep",
+			want:       map[string]string{"binary": "true", "diff": "true", "merge": "false", "text": "false"},
+			wantForced: true,
+		},
+		{path: "a.line", want: map[string]string{"binary": "false"}},
+		{
+			path:       "a.rev",
+			want:       map[string]string{"binary": "true", "diff": "false", "merge": "false", "text": "false"},
+			wantForced: true,
+			wantBinary: true,
+		},
+		{path: "sub/a.ign", want: map[string]string{"ignored": "true"}},
+	}
+
+	if got := newAttributeResolver(nil).attributes("a.dat"); len(got) != 0 {
+		t.Errorf("attributes without tree = %v, want none", got)
+	}
+
+	r := newAttributeResolver(tree)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			attrs := r.attributes(tt.path)
+			if got := attributeValues(attrs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("attributes = %v, want %v", got, tt.want)
+			}
+			binary, forced := diffAttribute(attrs)
+			if forced != tt.wantForced || binary != tt.wantBinary {
+				t.Errorf("diffAttribute() = %v, %v, want %v, %v", binary, forced, tt.wantBinary, tt.wantForced)
+			}
+		})
+	}
+}
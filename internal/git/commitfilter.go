@@ -0,0 +1,172 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// commitRules compiled commit filters of the commit_filter configuration
+type commitRules struct {
+	authors           []*regexp.Regexp
+	excludeAuthors    []*regexp.Regexp
+	committers        []*regexp.Regexp
+	excludeCommitters []*regexp.Regexp
+	messages          []*regexp.Regexp
+	excludeMessages   []*regexp.Regexp
+	types             []string  // Lower cased Conventional Commits types to analyze
+	excludeTypes      []string  // Lower cased Conventional Commits types to skip
+	since             time.Time // Zero if not set
+	until             time.Time // Zero if not set
+	minParents        int
+	maxParents        int // 0 means no limit
+}
+
+// compileCommitRules compiles the regular expressions and dates of the commit filters
+func compileCommitRules(cfg config.CommitFilterConfig) (*commitRules, error) {
+	rules := &commitRules{minParents: cfg.MinParents, maxParents: cfg.MaxParents}
+	for _, t := range cfg.Types {
+		rules.types = append(rules.types, strings.ToLower(t))
+	}
+	for _, t := range cfg.ExcludeTypes {
+		rules.excludeTypes = append(rules.excludeTypes, strings.ToLower(t))
+	}
+
+	for _, list := range []struct {
+		patterns []string
+		target   *[]*regexp.Regexp
+		name     string
+	}{
+		{cfg.Authors, &rules.authors, "authors"},
+		{cfg.ExcludeAuthors, &rules.excludeAuthors, "exclude_authors"},
+		{cfg.Committers, &rules.committers, "committers"},
+		{cfg.ExcludeCommitters, &rules.excludeCommitters, "exclude_committers"},
+		{cfg.Messages, &rules.messages, "messages"},
+		{cfg.ExcludeMessages, &rules.excludeMessages, "exclude_messages"},
+	} {
+		compiled, err := types.CompileRegexps("commit_filter."+list.name, list.patterns)
+		if err != nil {
+			return nil, err
+		}
+		*list.target = compiled
+	}
+
+	var err error
+	if rules.since, err = parseFilterDate(cfg.Since, false); err != nil {
+		return nil, fmt.Errorf("invalid commit_filter.since: %w", err)
+	}
+	if rules.until, err = parseFilterDate(cfg.Until, true); err != nil {
+		return nil, fmt.Errorf("invalid commit_filter.until: %w", err)
+	}
+
+	return rules, nil
+}
+
+// parseFilterDate parses an RFC 3339 time or a local date (YYYY-MM-DD). A date
+// means the start of the day, or its end if endOfDay is set, so date windows
+// include both of their days.
+func parseFilterDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD date, got %q", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// SkipCommit checks a commit against the commit filters of the configuration and
+// returns the reason the commit is skipped, empty if it is analyzed.
+// Only commit metadata is read, so filtering happens before any diffing.
+func (a *Analyzer) SkipCommit(commit *object.Commit) string {
+	rules := a.commits
+
+	if parents := commit.NumParents(); parents < rules.minParents {
+		return fmt.Sprintf("commit has %d parents, fewer than min_parents %d", parents, rules.minParents)
+	} else if rules.maxParents > 0 && parents > rules.maxParents {
+		return fmt.Sprintf("commit has %d parents, more than max_parents %d", parents, rules.maxParents)
+	}
+
+	when := commit.Committer.When
+	if !rules.since.IsZero() && when.Before(rules.since) {
+		return fmt.Sprintf("committed %s, before since", when.Format(time.RFC3339))
+	}
+	if !rules.until.IsZero() && when.After(rules.until) {
+		return fmt.Sprintf("committed %s, after until", when.Format(time.RFC3339))
+	}
+
+	if reason := skipSignature("author", commit.Author, rules.authors, rules.excludeAuthors); reason != "" {
+		return reason
+	}
+	if reason := skipSignature("committer", commit.Committer, rules.committers, rules.excludeCommitters); reason != "" {
+		return reason
+	}
+
+	if pattern := firstMatch(rules.excludeMessages, commit.Message); pattern != "" {
+		return fmt.Sprintf("message matches exclude_messages pattern %q", pattern)
+	}
+	if len(rules.messages) > 0 && firstMatch(rules.messages, commit.Message) == "" {
+		return "message matches no messages pattern"
+	}
+
+	if len(rules.types) > 0 || len(rules.excludeTypes) > 0 {
+		commitType := ""
+		if conventional := types.ParseCommitMessage(commit.Message).Conventional; conventional != nil {
+			commitType = conventional.Type
+		}
+		if types.Contains(rules.excludeTypes, commitType) {
+			return fmt.Sprintf("type %q is in exclude_types", commitType)
+		}
+		if len(rules.types) > 0 && !types.Contains(rules.types, commitType) {
+			if commitType == "" {
+				return "message has no Conventional Commits header"
+			}
+			return fmt.Sprintf("type %q is not in types", commitType)
+		}
+	}
+
+	return ""
+}
+
+// skipSignature checks the name and email of an author or committer against
+// include and exclude patterns, a pattern matches if it matches either of them
+func skipSignature(role string, sig object.Signature, include, exclude []*regexp.Regexp) string {
+	who := fmt.Sprintf("%s <%s>", sig.Name, sig.Email)
+	for _, pattern := range exclude {
+		if pattern.MatchString(sig.Name) || pattern.MatchString(sig.Email) {
+			return fmt.Sprintf("%s %s matches exclude_%ss pattern %q", role, who, role, pattern.String())
+		}
+	}
+	if len(include) == 0 {
+		return ""
+	}
+	for _, pattern := range include {
+		if pattern.MatchString(sig.Name) || pattern.MatchString(sig.Email) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s %s matches no %ss pattern", role, who, role)
+}
+
+// firstMatch returns the first pattern matching s, empty if none does
+func firstMatch(patterns []*regexp.Regexp, s string) string {
+	for _, pattern := range patterns {
+		if pattern.MatchString(strings.TrimSpace(s)) {
+			return pattern.String()
+		}
+	}
+	return ""
+}
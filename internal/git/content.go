@@ -0,0 +1,147 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// binaryCheckSize number of leading bytes checked for binary content, the same as git
+const binaryCheckSize = 8000
+
+// maxInvalidUTF8Ratio share of invalid UTF-8 bytes above which content is binary
+const maxInvalidUTF8Ratio = 0.3
+
+// isBinaryContent reports whether the leading bytes of a blob look binary,
+// that is they contain a NUL byte or are mostly invalid UTF-8
+func isBinaryContent(head []byte) bool {
+	if len(head) > binaryCheckSize {
+		head = head[:binaryCheckSize]
+	}
+	if len(head) == 0 {
+		return false
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+
+	invalid := 0
+	for i := 0; i < len(head); {
+		r, size := utf8.DecodeRune(head[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A rune cut off by the end of the sample is not invalid
+			if !utf8.FullRune(head[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return float64(invalid)/float64(len(head)) > maxInvalidUTF8Ratio
+}
+
+// readBlobHead reads the leading bytes of a blob used for binary detection
+func readBlobHead(s storer.EncodedObjectStorer, hash plumbing.Hash) ([]byte, error) {
+	blob, err := object.GetBlob(s, hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	head := make([]byte, binaryCheckSize)
+	n, err := io.ReadFull(reader, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return head[:n], err
+}
+
+// blobContent line count, binary flag and leading lines of a blob
+type blobContent struct {
+	lines       int    // Number of lines, a last line without newline is counted
+	isBinary    bool   // Whether the content is binary
+	sample      string // Leading lines, empty for binary content
+	sampleLines int    // Number of lines in sample
+}
+
+// readBlobContent streams a blob, counting its lines and keeping up to maxLines
+// leading lines, all lines if maxLines is negative. With detectBinary reading
+// stops as soon as the content is detected as binary. visit is called with every
+// line without its newline if it is not nil.
+func readBlobContent(blob *object.Blob, maxLines int, detectBinary bool, visit func(string)) (blobContent, error) {
+	reader, err := blob.Reader()
+	if err != nil {
+		return blobContent{}, err
+	}
+	defer reader.Close()
+
+	return readContent(reader, maxLines, detectBinary, visit)
+}
+
+// readContent streams content like readBlobContent
+func readContent(reader io.Reader, maxLines int, detectBinary bool, visit func(string)) (blobContent, error) {
+	var result blobContent
+
+	var sample strings.Builder
+	var current []byte // Line passed to visit, collected across buffer sized parts
+	buf := bufio.NewReader(reader)
+	head := make([]byte, 0, binaryCheckSize)
+
+	for {
+		line, err := buf.ReadSlice('\n')
+		if len(line) > 0 {
+			// Binary content is detected from the start of the blob
+			if detectBinary && len(head) < binaryCheckSize {
+				head = append(head, line[:min(len(line), binaryCheckSize-len(head))]...)
+				if len(head) == binaryCheckSize && isBinaryContent(head) {
+					return blobContent{isBinary: true}, nil
+				}
+			}
+
+			lineEnd := line[len(line)-1] == '\n' || err == io.EOF
+			if lineEnd {
+				result.lines++
+			}
+			if visit != nil {
+				current = append(current, line...)
+				if lineEnd {
+					visit(strings.TrimRight(string(current), "\r\n"))
+					current = current[:0]
+				}
+			}
+			if maxLines < 0 || result.sampleLines < maxLines {
+				sample.Write(line)
+				if lineEnd {
+					result.sampleLines++
+				}
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			// Part of a long line, it is counted when its newline is read
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if detectBinary && isBinaryContent(head) {
+		return blobContent{isBinary: true}, nil
+	}
+	result.sample = sample.String()
+	return result, nil
+}
@@ -1,27 +1,114 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/sirupsen/logrus"
 
 	"warmy/internal/config"
 	"warmy/internal/focus"
 	"warmy/internal/logger"
+	"warmy/internal/secrets"
+	"warmy/internal/severity"
 	"warmy/internal/types"
 )
 
-var log logger.Logger
+// Analyzer analyzes commits with a fixed configuration, logger and focus engine.
+// Apart from its locked commit graph caches an Analyzer holds no mutable state, and
+// every call reads through its own repository instance, so it is safe for concurrent
+// use, also by calls sharing a *git.Repository.
+type Analyzer struct {
+	cfg        *config.Config
+	log        logger.Logger
+	focus      *focus.Engine
+	graphs     *graphCache
+	paths      *pathRules
+	commits    *commitRules
+	signatures *signatureVerifier
+	secrets    *secrets.Scanner
+	severity   *severity.Tracker
+}
+
+// New creates an analyzer from configuration, focus rules are taken from cfg.Focus
+func New(cfg *config.Config, log logger.Logger) (*Analyzer, error) {
+	switch cfg.MergeStrategy {
+	case "", config.MergeFirstParent, config.MergeEachParent, config.MergeCombined, config.MergeSkip:
+	default:
+		return nil, fmt.Errorf("invalid merge_strategy %q, expected %s, %s, %s or %s", cfg.MergeStrategy,
+			config.MergeFirstParent, config.MergeEachParent, config.MergeCombined, config.MergeSkip)
+	}
+	switch cfg.RootCommit.Mode {
+	case "", config.RootCommitSummary, config.RootCommitFull:
+	default:
+		return nil, fmt.Errorf("invalid root_commit.mode %q, expected %s or %s", cfg.RootCommit.Mode,
+			config.RootCommitSummary, config.RootCommitFull)
+	}
+
+	paths, err := compilePathRules(cfg.Paths)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := compileCommitRules(cfg.CommitFilter)
+	if err != nil {
+		return nil, err
+	}
+	signatures, err := newSignatureVerifier(cfg.Signatures)
+	if err != nil {
+		return nil, err
+	}
+	secretScanner, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret scanning: %w", err)
+	}
+	severityTracker, err := severity.New(cfg.SeverityTracking)
+	if err != nil {
+		return nil, err
+	}
+
+	focusEngine, err := focus.New(cfg.Focus, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize focus feature: %w", err)
+	}
+
+	return &Analyzer{
+		cfg:        cfg,
+		log:        log,
+		focus:      focusEngine,
+		graphs:     newGraphCache(),
+		paths:      paths,
+		commits:    commits,
+		signatures: signatures,
+		secrets:    secretScanner,
+		severity:   severityTracker,
+	}, nil
+}
+
+// Config returns configuration of the analyzer
+func (a *Analyzer) Config() *config.Config {
+	return a.cfg
+}
+
+// Logger returns logger of the analyzer
+func (a *Analyzer) Logger() logger.Logger {
+	return a.log
+}
 
 // GetCommit gets complete information of specified commit
-func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
-	log = logger.GetLogger()
+func (a *Analyzer) GetCommit(ctx context.Context, repoPath, commitHash string) (*types.CommitInfo, error) {
+	log := a.log
 
 	log.WithFields(logger.Fields{
 		"repo_path":   repoPath,
@@ -40,72 +127,80 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 
 	log.Debug("Successfully opened local repository")
 
-	var commit *object.Commit
+	commit, err := a.ResolveCommit(repo, commitHash)
+	if err != nil {
+		return nil, err
+	}
 
-	if commitHash == "" {
-		// If no commit hash specified, get latest commit
-		ref, err := repo.Head()
-		if err != nil {
-			log.WithFields(logger.Fields{
-				"repo_path": repoPath,
-				"error":     err.Error(),
-			}).Error("Failed to get HEAD reference")
-			return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
-		}
+	return a.AnalyzeCommit(ctx, repo, commit)
+}
+
+// ResolveCommit resolves a revision to its commit object, an empty revision means HEAD.
+// Full and abbreviated hashes, branches, remote branches, tags and revision
+// expressions such as main~3, HEAD^2 or v1.2.0^{/fix} are accepted.
+func (a *Analyzer) ResolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	log := a.log
+
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	repo, err := openPrivate(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
 
+	// Abbreviated hashes matching several commits must not silently pick one
+	if err := checkAmbiguousRevision(repo, rev); err != nil {
 		log.WithFields(logger.Fields{
-			"ref":  ref.Name().String(),
-			"hash": ref.Hash().String(),
-		}).Debug("Got HEAD reference")
+			"revision": rev,
+			"error":    err.Error(),
+		}).Error("Ambiguous revision")
+		return nil, err
+	}
 
-		// Get commit object
-		commit, err = repo.CommitObject(ref.Hash())
-		if err != nil {
-			log.WithFields(logger.Fields{
-				"hash":  ref.Hash().String(),
-				"error": err.Error(),
-			}).Error("Failed to get commit object")
-			return nil, fmt.Errorf("failed to get commit object: %w", err)
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		if err == io.EOF {
+			// Walking past the first or last parent of a commit
+			err = fmt.Errorf("commit has no such parent")
 		}
-	} else {
-		// Parse specified commit hash
-		hash := plumbing.NewHash(commitHash)
-		commit, err = repo.CommitObject(hash)
-		if err != nil {
-			// Try to find short hash
-			commitIter, err := repo.CommitObjects()
-			if err != nil {
-				log.WithFields(logger.Fields{
-					"hash":  commitHash,
-					"error": err.Error(),
-				}).Error("Failed to iterate commit objects")
-				return nil, fmt.Errorf("failed to iterate commit objects: %w", err)
-			}
+		log.WithFields(logger.Fields{
+			"revision": rev,
+			"error":    err.Error(),
+		}).Error("Failed to resolve revision")
+		return nil, fmt.Errorf("failed to resolve revision %s: %w", rev, err)
+	}
 
-			var foundCommit *object.Commit
-			err = commitIter.ForEach(func(c *object.Commit) error {
-				if strings.HasPrefix(c.Hash.String(), commitHash) {
-					foundCommit = c
-					return fmt.Errorf("found") // Break iteration
-				}
-				return nil
-			})
-
-			if foundCommit != nil {
-				commit = foundCommit
-			} else if err != nil && err.Error() != "found" {
-				log.WithFields(logger.Fields{
-					"hash":  commitHash,
-					"error": err.Error(),
-				}).Error("Failed to find commit")
-				return nil, fmt.Errorf("failed to find commit: %w", err)
-			} else {
-				log.WithFields(logger.Fields{
-					"hash": commitHash,
-				}).Error("Specified commit not found")
-				return nil, fmt.Errorf("specified commit not found: %s", commitHash)
-			}
-		}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		log.WithFields(logger.Fields{
+			"hash":  hash.String(),
+			"error": err.Error(),
+		}).Error("Failed to get commit object")
+		return nil, fmt.Errorf("failed to get commit object: %w", err)
+	}
+
+	log.WithFields(logger.Fields{
+		"revision": rev,
+		"hash":     commit.Hash.String(),
+	}).Debug("Resolved revision")
+
+	return commit, nil
+}
+
+// AnalyzeCommit builds complete information of the given commit
+func (a *Analyzer) AnalyzeCommit(ctx context.Context, repo *git.Repository, commit *object.Commit) (*types.CommitInfo, error) {
+	log := a.log
+
+	// The commit is read again through the private repository, it may be bound to
+	// the storage of the caller
+	repo, err := openPrivate(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if commit, err = repo.CommitObject(commit.Hash); err != nil {
+		return nil, fmt.Errorf("failed to get commit object: %w", err)
 	}
 
 	log.WithFields(logger.Fields{
@@ -114,27 +209,52 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 		"message":     strings.Split(commit.Message, "\n")[0],
 	}).Info("Got commit object")
 
-	// Get branch information
-	branches, err := getBranchesContainingCommit(repo, commit.Hash)
+	// Get branches and tags containing the commit, and the nearest tag
+	branches, tags := []string{}, []string{}
+	var describe *types.DescribeInfo
+	var tagSignatures []types.TagSignatureInfo
+	tips, err := listRefTips(repo)
 	if err != nil {
-		log.WithError(err).Warn("Failed to get branch information")
-		branches = []string{}
+		log.WithError(err).Warn("Failed to list references")
+	} else {
+		memo := make(map[plumbing.Hash]bool)
+
+		if branches, err = a.refsContainingCommit(repo, commit.Hash, tips.branches, memo); err != nil {
+			log.WithError(err).Warn("Failed to get branch information")
+			branches = []string{}
+		}
+
+		if tags, err = a.refsContainingCommit(repo, commit.Hash, tips.tags, memo); err != nil {
+			log.WithError(err).Warn("Failed to get tag information")
+			tags = []string{}
+		}
+
+		if describe, err = a.describeCommit(repo, commit.Hash, tips.tags); err != nil {
+			log.WithError(err).Warn("Failed to get nearest tag")
+			describe = nil
+		}
+
+		tagSignatures = a.tagSignatures(repo, commit.Hash, tips.tags)
 	}
 
-	// Get tag information
-	tags, err := getTagsContainingCommit(repo, commit.Hash)
-	if err != nil {
-		log.WithError(err).Warn("Failed to get tag information")
-		tags = []string{}
+	// Verify commit signature
+	signature := a.signatures.verifyObject(commit.PGPSignature, commit.EncodeWithoutSignature)
+	if signature != nil {
+		log.WithFields(logger.Fields{
+			"format": signature.Format,
+			"status": signature.Status,
+			"key_id": signature.KeyID,
+		}).Debug("Verified commit signature")
 	}
 
 	// Parse commit message
-	message := strings.TrimSpace(commit.Message)
-	subject, description := types.SplitCommitMessage(message)
+	message := types.ParseCommitMessage(commit.Message)
 
 	log.WithFields(logger.Fields{
-		"subject_length":     len(subject),
-		"description_length": len(description),
+		"subject_length":     len(message.Subject),
+		"description_length": len(message.Description),
+		"conventional":       message.Conventional != nil,
+		"trailers":           len(message.Trailers),
 	}).Debug("Parsed commit message")
 
 	// Get parent commit hashes
@@ -172,7 +292,10 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 	}).Debug("Got tree object")
 
 	// Get change information
-	changes, stats, diffSummary, err := getCommitChanges(repo, commit)
+	changes, stats, diffSummary, err := a.getCommitChanges(ctx, repo, commit)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		log.WithFields(logger.Fields{
 			"commit": commit.Hash.String(),
@@ -191,44 +314,97 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 		}).Info("Successfully got change information")
 	}
 
+	// Check message rules
+	messageFocus, isFocus, suppressFocus := a.focus.CheckMessage(&message)
+
+	// Check author trust lists, whether the author is a new contributor is only
+	// determined on the first focus file as it walks the history
+	authorTrust := a.focus.CheckAuthor(commit.Author.Name, commit.Author.Email, false)
+	checkNewContributor := authorTrust == "" && a.focus.DetectsNewContributors()
+
 	// Build changed file list
 	filesChanged := make([]string, 0, len(changes))
 	focusFiles := make([]types.FocusFileInfo, 0)
 	focusStats := types.FocusStats{}
+	if isFocus {
+		focusStats.FocusCommits = 1
+	}
 
-	// Initialize focus feature
-	if err := focus.Init(); err != nil {
-		log.WithError(err).Warn("Failed to initialize focus feature")
-	} else {
-		for i := range changes {
-			change := &changes[i]
+	seenFiles := make(map[string]bool, len(changes))
+	seenFocusFiles := make(map[string]bool)
+	var secretFindings []types.SecretFinding
+	var severityChanges []types.SeverityChange
+
+	for i := range changes {
+		change := &changes[i]
+		// Merge commits compared with each parent may list a file once per parent
+		if !seenFiles[change.Filepath] {
+			seenFiles[change.Filepath] = true
 			filesChanged = append(filesChanged, change.Filepath)
+		}
+		secretFindings = append(secretFindings, change.Secrets...)
+		if change.Severity != nil {
+			severityChanges = append(severityChanges, *change.Severity)
+		}
 
-			// Check if change is focus
-			if focusFile, isFocus := focus.CheckFocusChange(change); isFocus {
-				focusFiles = append(focusFiles, *focusFile)
-
-				// Statistics
-				focusStats.TotalFocusFiles++
-				if change.Action == "add" {
-					focusStats.AddFocusFiles++
-					focusStats.MatchPatternFiles++
-				} else if change.Action == "modify" {
-					focusStats.ModifyFocusFiles++
-					focusStats.MatchContentFiles++
-				} else if change.Action == "delete" {
-					// Delete files don't have content to match, so they're counted as pattern files
-					focusStats.MatchPatternFiles++
-				}
+		// Check if change is focus
+		if focusFile, isFocus := a.focus.CheckFocusChange(change); isFocus {
+			// A suppress message rule drops the focus of every file of the commit
+			if suppressFocus {
+				change.IsFocus = false
+				change.FocusReason = ""
+				focusStats.SuppressedFiles++
+				continue
+			}
+
+			if checkNewContributor {
+				checkNewContributor = false
+				authorTrust = a.newContributorTrust(repo, commit)
+			}
+			a.focus.ApplyAuthorTrust(change, focusFile, authorTrust)
+			a.focus.ApplySeverityChange(change, focusFile)
+			a.focus.ApplySignature(change, focusFile, signature)
+			focusFiles = append(focusFiles, *focusFile)
+
+			// Statistics
+			focusStats.TotalFocusFiles++
+			if !seenFocusFiles[change.Filepath] {
+				seenFocusFiles[change.Filepath] = true
+				focusStats.UniqueFocusFiles++
+			}
+			if change.Action == "add" {
+				focusStats.AddFocusFiles++
+				focusStats.MatchPatternFiles++
+			} else if change.Action == "modify" {
+				focusStats.ModifyFocusFiles++
+				focusStats.MatchContentFiles++
+			} else if change.Action == "delete" {
+				// Delete files don't have content to match, so they're counted as pattern files
+				focusStats.MatchPatternFiles++
 			}
 		}
 	}
 
+	stats.UniqueFiles = len(filesChanged)
+
 	log.WithFields(logger.Fields{
 		"file_count":  len(filesChanged),
 		"focus_count": len(focusFiles),
+		"suppressed":  focusStats.SuppressedFiles,
 	}).Debug("Built changed file list")
 
+	if len(secretFindings) > 0 {
+		log.WithFields(logger.Fields{
+			"secrets": len(secretFindings),
+		}).Warn("Found secrets in added lines")
+	}
+
+	// Record how a merge commit was compared with its parents
+	mergeStrategy := ""
+	if commit.NumParents() > 1 {
+		mergeStrategy = a.cfg.MergeStrategy
+	}
+
 	// Get current time
 	currentTime := time.Now()
 	analyzeTime := currentTime.Format("20060102-150405")
@@ -247,21 +423,32 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 			Email: commit.Committer.Email,
 			When:  commit.Committer.When.Format("2006-01-02 15:04:05 -0700"),
 		},
-		Message:      subject,
-		Description:  description,
-		FullMessage:  message,
-		ParentHashes: parentHashes,
-		Changes:      changes,
-		FocusFiles:   focusFiles,
-		Timestamp:    commit.Committer.When.Unix(),
-		TreeHash:     tree.Hash.String(),
-		FilesChanged: filesChanged,
-		Stats:        stats,
-		DiffSummary:  diffSummary,
-		Branches:     branches,
-		Tags:         tags,
-		AnalyzeTime:  analyzeTime,
-		FocusStats:   focusStats,
+		Message:         message.Subject,
+		Description:     message.Description,
+		FullMessage:     message.FullMessage,
+		Conventional:    message.Conventional,
+		Trailers:        message.Trailers,
+		ParentHashes:    parentHashes,
+		MergeStrategy:   mergeStrategy,
+		Changes:         changes,
+		FocusFiles:      focusFiles,
+		IsFocus:         isFocus,
+		MessageFocus:    messageFocus,
+		AuthorTrust:     authorTrust,
+		Signature:       signature,
+		TagSignatures:   tagSignatures,
+		Secrets:         secretFindings,
+		SeverityChanges: severityChanges,
+		Timestamp:       commit.Committer.When.Unix(),
+		TreeHash:        tree.Hash.String(),
+		FilesChanged:    filesChanged,
+		Stats:           stats,
+		DiffSummary:     diffSummary,
+		Branches:        branches,
+		Tags:            tags,
+		Describe:        describe,
+		AnalyzeTime:     analyzeTime,
+		FocusStats:      focusStats,
 	}
 
 	log.WithFields(logger.Fields{
@@ -278,77 +465,202 @@ func GetCommit(repoPath, commitHash string) (*types.CommitInfo, error) {
 	return commitInfo, nil
 }
 
-// getBranchesContainingCommit gets branches containing specified commit
-func getBranchesContainingCommit(repo *git.Repository, hash plumbing.Hash) ([]string, error) {
-	branches := []string{}
+// maxDescribeCandidates number of nearest tagged commits compared when describing a commit
+const maxDescribeCandidates = 10
+
+// refTip branch or tag with the commit it points to
+type refTip struct {
+	name      string        // Short reference name
+	commit    plumbing.Hash // Commit hash, annotated tags are peeled
+	annotated bool          // Annotated tag
+	object    plumbing.Hash // Tag object of an annotated tag
+}
+
+// refTips branches and tags of a repository
+type refTips struct {
+	branches []refTip // Local and remote branches
+	tags     []refTip // Tags
+}
+
+// listRefTips lists local and remote branches and tags with the commits they point to
+func listRefTips(repo *git.Repository) (*refTips, error) {
+	tips := &refTips{}
 
-	// Get all branch references
 	refs, err := repo.References()
 	if err != nil {
 		return nil, err
 	}
 
 	err = refs.ForEach(func(ref *plumbing.Reference) error {
-		// Only process local and remote branches
-		if ref.Name().IsBranch() || strings.HasPrefix(ref.Name().String(), "refs/remotes/") {
-			// Simplified: only check if current reference points to this commit
-			if ref.Hash() == hash {
-				branches = append(branches, ref.Name().Short())
+		// Symbolic references such as refs/remotes/origin/HEAD duplicate a branch
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		name := ref.Name()
+		switch {
+		case name.IsBranch() || name.IsRemote():
+			tips.branches = append(tips.branches, refTip{name: name.Short(), commit: ref.Hash()})
+		case name.IsTag():
+			tip := refTip{name: name.Short(), commit: ref.Hash(), object: ref.Hash()}
+
+			// Peel annotated tags, tags of tags included
+			for {
+				tag, err := repo.TagObject(tip.commit)
+				if err == plumbing.ErrObjectNotFound {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				tip.commit = tag.Target
+				tip.annotated = true
 			}
+			tips.tags = append(tips.tags, tip)
 		}
 		return nil
 	})
 
-	return branches, err
+	return tips, err
 }
 
-// getTagsContainingCommit gets tags containing specified commit
-func getTagsContainingCommit(repo *git.Repository, hash plumbing.Hash) ([]string, error) {
-	tags := []string{}
+// refsContainingCommit gets names of the references whose history contains the commit.
+// References not pointing to a commit are skipped.
+func (a *Analyzer) refsContainingCommit(repo *git.Repository, hash plumbing.Hash, tips []refTip, memo map[plumbing.Hash]bool) ([]string, error) {
+	names := []string{}
+	graph := a.graphs.get(repo)
 
-	// Get all tag references
-	refs, err := repo.References()
+	for _, tip := range tips {
+		ok, err := graph.contains(repo, tip.commit, hash, memo)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			names = append(names, tip.name)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// newContributorTrust returns the new contributor trust level if no ancestor of the
+// commit has its author email, empty otherwise. An author without email cannot be
+// matched to earlier commits and is never a new contributor.
+func (a *Analyzer) newContributorTrust(repo *git.Repository, commit *object.Commit) string {
+	if strings.TrimSpace(commit.Author.Email) == "" {
+		return a.focus.CheckAuthor(commit.Author.Name, commit.Author.Email, false)
+	}
+
+	earlier, err := a.graphs.get(repo).hasEarlierAuthor(repo, commit.Hash)
 	if err != nil {
-		return nil, err
+		a.log.WithError(err).Warn("Failed to check earlier commits of the author")
 	}
+	return a.focus.CheckAuthor(commit.Author.Name, commit.Author.Email, err == nil && !earlier)
+}
 
-	err = refs.ForEach(func(ref *plumbing.Reference) error {
-		// Only process tags
-		if ref.Name().IsTag() {
-			// If annotated tag, need to parse tag object
-			var tagCommit plumbing.Hash
-
-			obj, err := repo.TagObject(ref.Hash())
-			if err == nil {
-				// Annotated tag
-				tagCommit = obj.Target
-			} else if err == plumbing.ErrObjectNotFound {
-				// Lightweight tag
-				tagCommit = ref.Hash()
-			} else {
-				return err
+// describeCommit finds the nearest tag reachable from the commit and the number
+// of commits since that tag, like git describe --tags. Returns nil if no tag is reachable.
+func (a *Analyzer) describeCommit(repo *git.Repository, hash plumbing.Hash, tags []refTip) (*types.DescribeInfo, error) {
+	graph := a.graphs.get(repo)
+	tagged := make(map[plumbing.Hash][]refTip)
+	var minGeneration uint64
+	for _, tag := range tags {
+		n, err := graph.node(repo, tag.commit)
+		if err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				continue
 			}
+			return nil, err
+		}
+		tagged[tag.commit] = append(tagged[tag.commit], tag)
+		if minGeneration == 0 || n.generation < minGeneration {
+			minGeneration = n.generation
+		}
+	}
+	if len(tagged) == 0 {
+		return nil, nil
+	}
 
-			if tagCommit == hash {
-				tags = append(tags, ref.Name().Short())
-			}
+	commitNode, err := graph.node(repo, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare the nearest tagged commits and keep the one with the fewest commits in
+	// between, the most recent on ties. A commit generations below cannot be nearer
+	// than its generation difference, which ends the walk early.
+	best, bestDistance := plumbing.ZeroHash, -1
+	checked := 0
+	var countErr error
+	err = graph.walkAncestors(repo, hash, func(c plumbing.Hash, generation uint64) bool {
+		if generation < minGeneration {
+			return false
 		}
-		return nil
+		if bestDistance >= 0 && uint64(bestDistance) <= commitNode.generation-generation {
+			return false
+		}
+		if _, ok := tagged[c]; !ok {
+			return true
+		}
+
+		distance, err := graph.countBetween(repo, hash, c)
+		if err != nil {
+			countErr = err
+			return false
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = c, distance
+		}
+
+		checked++
+		return checked < maxDescribeCandidates
+	})
+	if err != nil {
+		return nil, err
+	}
+	if countErr != nil {
+		return nil, countErr
+	}
+	if bestDistance < 0 {
+		return nil, nil
+	}
+
+	// Annotated tags are preferred over lightweight tags of the same commit
+	candidateTags := tagged[best]
+	sort.Slice(candidateTags, func(i, j int) bool {
+		if candidateTags[i].annotated != candidateTags[j].annotated {
+			return candidateTags[i].annotated
+		}
+		return candidateTags[i].name < candidateTags[j].name
 	})
+	tag := candidateTags[0].name
 
-	return tags, err
+	description := tag
+	if bestDistance > 0 {
+		description = fmt.Sprintf("%s-%d-g%s", tag, bestDistance, hash.String()[:7])
+	}
+
+	return &types.DescribeInfo{
+		Tag:         tag,
+		Distance:    bestDistance,
+		Description: description,
+	}, nil
 }
 
 // getCommitChanges gets change information of commit
-func getCommitChanges(repo *git.Repository, commit *object.Commit) ([]types.ChangeInfo, types.StatsInfo, types.DiffSummary, error) {
+func (a *Analyzer) getCommitChanges(ctx context.Context, repo *git.Repository, commit *object.Commit) ([]types.ChangeInfo, types.StatsInfo, types.DiffSummary, error) {
 	changes := make([]types.ChangeInfo, 0)
 	stats := types.StatsInfo{}
-	cfg := config.GetConfig()
+	cfg := a.cfg
 	diffSummary := types.DiffSummary{
 		MaxDiffSize: cfg.MaxDiffSize,
 	}
 
-	log := logger.GetLogger().WithFields(logger.Fields{
+	log := a.log.WithFields(logger.Fields{
 		"commit": commit.Hash.String(),
 	})
 
@@ -356,366 +668,901 @@ func getCommitChanges(repo *git.Repository, commit *object.Commit) ([]types.Chan
 
 	// If initial commit, no parent
 	if commit.NumParents() == 0 {
-		log.Info("This is initial commit, getting all files")
+		return a.getInitialCommitChanges(ctx, repo, commit, log)
+	}
+
+	// Merge commits are compared according to the merge strategy
+	strategy := config.MergeFirstParent
+	if commit.NumParents() > 1 {
+		strategy = cfg.MergeStrategy
+	}
+	if strategy == config.MergeSkip {
+		log.Info("Skipped changes of merge commit")
+		return changes, stats, diffSummary, nil
+	}
+
+	// Get parent commit trees, the first parent is usually the most direct previous commit
+	parentCount := 1
+	if strategy != config.MergeFirstParent {
+		parentCount = commit.NumParents()
+	}
+	parentTrees := make([]*object.Tree, 0, parentCount)
+	for i := 0; i < parentCount; i++ {
+		parent, err := commit.Parent(i)
+		if err != nil {
+			log.WithError(err).Error("Failed to get parent commit")
+			return changes, stats, diffSummary, err
+		}
+
+		log.WithFields(logger.Fields{
+			"parent_hash": parent.Hash.String(),
+		}).Debug("Got parent commit")
 
-		// Get all files in tree
-		tree, err := commit.Tree()
+		parentTree, err := parent.Tree()
 		if err != nil {
-			log.WithError(err).Error("Failed to get tree object")
+			log.WithError(err).Error("Failed to get parent commit tree")
 			return changes, stats, diffSummary, err
 		}
+		parentTrees = append(parentTrees, parentTree)
+	}
+
+	// Get current commit tree
+	currentTree, err := commit.Tree()
+	if err != nil {
+		log.WithError(err).Error("Failed to get current commit tree")
+		return changes, stats, diffSummary, err
+	}
 
-		// Iterate all files
-		fileCount := 0
-		totalDiffSize := 0
-		err = tree.Files().ForEach(func(f *object.File) error {
-			// Get file size
-			size := f.Size
+	// Filtered paths are dropped before diffing
+	filter := a.newPathFilter(currentTree)
 
-			// Get file content
-			content, err := f.Contents()
-			var diffContent string
+	var results []fileChangeResult
+	switch strategy {
+	case config.MergeEachParent:
+		// Changes against every parent, each attributed to its parent
+		for i, parentTree := range parentTrees {
+			parentResults, err := a.diffTrees(ctx, repo, parentTree, currentTree, filter.keepChange, log)
 			if err != nil {
-				diffContent = fmt.Sprintf("// Unable to read file content: %v\n", err)
-				stats.BinaryFiles++
-			} else {
-				// Generate diff for initial commit (full file content)
-				diffContent = fmt.Sprintf("+++ b/%s\n@@ -0,0 +1,%d @@\n%s",
-					f.Name, len(strings.Split(content, "\n")), content)
+				return changes, stats, diffSummary, err
 			}
-
-			change := types.ChangeInfo{
-				Action:      "add",
-				Filepath:    f.Name,
-				Additions:   len(strings.Split(content, "\n")),
-				Deletions:   0,
-				DiffContent: diffContent,
-				Extension:   types.GetFileExtension(f.Name),
-				FileSize:    size,
-				IsBinary:    err != nil, // If cannot read content, might be binary file
+			for j := range parentResults {
+				parentResults[j].change.Parent = commit.ParentHashes[i].String()
 			}
+			results = append(results, parentResults...)
+		}
+	case config.MergeCombined:
+		// Only files differing from every parent, diffed against the first parent
+		changed, err := changedPathsAgainstAll(ctx, parentTrees[1:], currentTree)
+		if err != nil {
+			log.WithError(err).Error("Failed to compare merge parents")
+			return changes, stats, diffSummary, err
+		}
+		results, err = a.diffTrees(ctx, repo, parentTrees[0], currentTree, func(change *object.Change) bool {
+			return (changed[change.From.Name] || changed[change.To.Name]) && filter.keepChange(change)
+		}, log)
+		if err != nil {
+			return changes, stats, diffSummary, err
+		}
+	default:
+		results, err = a.diffTrees(ctx, repo, parentTrees[0], currentTree, filter.keepChange, log)
+		if err != nil {
+			return changes, stats, diffSummary, err
+		}
+	}
+
+	a.applyReportBudget(results)
+
+	// Merge results in order
+	var fullDiff strings.Builder
+	totalDiffSize := 0
+
+	for _, result := range results {
+		if !result.ok {
+			continue
+		}
+
+		changes = append(changes, result.change)
+		stats.Add(result.stats)
+		totalDiffSize += len(result.change.DiffContent)
+		if result.diffTooLarge {
+			diffSummary.DiffTooLarge = true
+		}
+		if result.change.Truncated {
+			diffSummary.TruncatedFiles++
+		}
+		if cfg.IncludeFullDiff.Value() {
+			fullDiff.WriteString(result.change.DiffContent + "\n\n")
+		}
+	}
+
+	stats.TotalFiles = len(changes)
+	stats.FilteredFiles = filter.filteredFiles()
+	diffSummary.TotalDiffSize = totalDiffSize
+
+	if cfg.IncludeFullDiff.Value() {
+		diffSummary.FullDiff = fullDiff.String()
+	}
+
+	log.WithFields(logger.Fields{
+		"total_files":     stats.TotalFiles,
+		"add_files":       stats.AddFiles,
+		"delete_files":    stats.DeleteFiles,
+		"modify_files":    stats.ModifyFiles,
+		"rename_files":    stats.RenameFiles,
+		"binary_files":    stats.BinaryFiles,
+		"lfs_files":       stats.LFSFiles,
+		"filtered_files":  stats.FilteredFiles,
+		"total_additions": stats.TotalAdditions,
+		"total_deletions": stats.TotalDeletions,
+		"total_diff_size": diffSummary.TotalDiffSize,
+		"diff_too_large":  diffSummary.DiffTooLarge,
+	}).Info("Change information statistics completed")
+
+	return changes, stats, diffSummary, nil
+}
+
+// diffTrees compares two trees and processes every file change, keep filters the
+// tree changes when not nil. Results keep the order of the tree changes.
+func (a *Analyzer) diffTrees(ctx context.Context, repo *git.Repository, fromTree, toTree *object.Tree, keep func(*object.Change) bool, log logger.Logger) ([]fileChangeResult, error) {
+	log.Debug("Started generating patch")
 
-			// Parse diff content
-			if cfg.ParseDiff && !change.IsBinary {
-				additions, _ := parseDiffContent(diffContent)
-				change.AdditionsList = additions
+	// Compare two trees, file patches are generated per change by the workers
+	treeChanges, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		log.WithError(err).Error("Failed to generate patch")
+		return nil, err
+	}
+
+	if keep != nil {
+		kept := treeChanges[:0]
+		for _, change := range treeChanges {
+			if keep(change) {
+				kept = append(kept, change)
 			}
+		}
+		treeChanges = kept
+	}
 
-			changes = append(changes, change)
-			stats.AddFiles++
-			fileCount++
+	workers := workerCount(a.cfg.Workers, len(treeChanges))
+	lfsStore := a.lfsObjectStore(repo)
 
-			// Count diff size
-			diffSize := len(diffContent)
-			totalDiffSize += diffSize
+	log.WithFields(logger.Fields{
+		"patch_files": len(treeChanges),
+		"workers":     workers,
+	}).Debug("Tree comparison completed")
 
-			// Check if single diff is too large
-			if diffSize > cfg.MaxDiffSize {
-				change.DiffContent = fmt.Sprintf("// Diff content too large (%d bytes), truncated", diffSize)
-				diffSummary.DiffTooLarge = true
+	// Process each file change
+	results := make([]fileChangeResult, len(treeChanges))
+	err = runWorkers(ctx, len(treeChanges), workers, func() (*workerTrees, error) {
+		return newWorkerTrees(repo, fromTree.Hash, toTree.Hash)
+	}, func(w *workerTrees, i int) error {
+		change := *treeChanges[i]
+		if change.From.Tree != nil {
+			change.From.Tree = w.from
+		}
+		if change.To.Tree != nil {
+			change.To.Tree = w.to
+		}
+
+		// Submodule entries point to commits, there is no content to diff
+		if change.From.TreeEntry.Mode == filemode.Submodule || change.To.TreeEntry.Mode == filemode.Submodule {
+			results[i] = a.processSubmoduleChange(i, &change, log)
+			setChangeKind(&results[i].change, &change)
+			return nil
+		}
+
+		// Deleted files use the attributes of the tree before the change
+		var attrs map[string]gitattributes.Attribute
+		if change.To.Name != "" {
+			attrs = w.toAttrs.attributes(change.To.Name)
+		} else {
+			attrs = w.fromAttrs.attributes(change.From.Name)
+		}
+
+		// LFS pointer files are reported with the objects they point to
+		fromPointer, toPointer, err := readChangePointers(w.storer, &change)
+		if err != nil {
+			return fmt.Errorf("failed to read content of %s: %w", treeChanges[i], err)
+		}
+		if fromPointer != nil || toPointer != nil {
+			results[i], err = a.processLFSChange(i, &change, fromPointer, toPointer, w, lfsStore, log)
+			if err != nil {
+				return fmt.Errorf("failed to read LFS objects of %s: %w", treeChanges[i], err)
 			}
+			results[i].change.Attributes = attributeValues(attrs)
+			setChangeKind(&results[i].change, &change)
+			return nil
+		}
 
+		// Binary files are reported without generating a text patch
+		binary, err := detectBinaryChange(w, &change, attrs)
+		if err != nil {
+			return fmt.Errorf("failed to read content of %s: %w", treeChanges[i], err)
+		}
+		if binary {
+			results[i] = a.processBinaryChange(i, &change, w, log)
+			results[i].change.Attributes = attributeValues(attrs)
+			setChangeKind(&results[i].change, &change)
 			return nil
-		})
+		}
 
+		patch, err := change.PatchContext(ctx)
 		if err != nil {
-			log.WithError(err).Error("Failed to iterate files")
-			return changes, stats, diffSummary, err
+			return fmt.Errorf("failed to generate patch for %s: %w", treeChanges[i], err)
 		}
 
-		stats.TotalFiles = len(changes)
-		diffSummary.TotalDiffSize = totalDiffSize
+		for _, filePatch := range patch.FilePatches() {
+			// go-git cannot build a text patch of content with NUL bytes,
+			// even if the diff attribute asks for one
+			if filePatch.IsBinary() {
+				results[i] = a.processBinaryChange(i, &change, w, log)
+				continue
+			}
+			results[i] = a.processFilePatch(i, filePatch, w.to, log)
+		}
+		results[i].change.Attributes = attributeValues(attrs)
+		setChangeKind(&results[i].change, &change)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to generate patch")
+		return nil, err
+	}
 
-		log.WithFields(logger.Fields{
-			"total_files":     fileCount,
-			"total_diff_size": totalDiffSize,
-		}).Debug("Initial commit file statistics completed")
+	return results, nil
+}
 
-		return changes, stats, diffSummary, nil
+// changedPathsAgainstAll returns paths of tree that differ from every one of the parent trees
+func changedPathsAgainstAll(ctx context.Context, parentTrees []*object.Tree, tree *object.Tree) (map[string]bool, error) {
+	var changed map[string]bool
+
+	for _, parentTree := range parentTrees {
+		treeChanges, err := object.DiffTreeWithOptions(ctx, parentTree, tree, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		paths := make(map[string]bool, len(treeChanges))
+		for _, change := range treeChanges {
+			if changed == nil || changed[change.From.Name] {
+				paths[change.From.Name] = true
+			}
+			if changed == nil || changed[change.To.Name] {
+				paths[change.To.Name] = true
+			}
+		}
+		delete(paths, "")
+		changed = paths
 	}
 
-	// Get first parent commit (usually the most direct previous commit)
-	parent, err := commit.Parent(0)
-	if err != nil {
-		log.WithError(err).Error("Failed to get parent commit")
-		return changes, stats, diffSummary, err
+	return changed, nil
+}
+
+// fileChangeResult result of processing a single file change
+type fileChangeResult struct {
+	ok           bool             // Whether the result is set
+	change       types.ChangeInfo // Change information
+	stats        types.StatsInfo  // Statistics of this file
+	diffSize     int              // Size of the complete diff
+	diffTooLarge bool             // Whether diff exceeded max diff size
+	parts        *diffParts       // Header and hunks of the diff, nil for binary, LFS and submodule changes
+}
+
+// getInitialCommitChanges gets change information of initial commit, every file is an added file
+func (a *Analyzer) getInitialCommitChanges(ctx context.Context, repo *git.Repository, commit *object.Commit, log logger.Logger) ([]types.ChangeInfo, types.StatsInfo, types.DiffSummary, error) {
+	changes := make([]types.ChangeInfo, 0)
+	stats := types.StatsInfo{}
+	cfg := a.cfg
+	diffSummary := types.DiffSummary{
+		MaxDiffSize: cfg.MaxDiffSize,
 	}
 
 	log.WithFields(logger.Fields{
-		"parent_hash": parent.Hash.String(),
-	}).Debug("Got parent commit")
+		"mode": cfg.RootCommit.Mode,
+	}).Info("This is initial commit, getting all files")
 
-	// Get parent commit tree
-	parentTree, err := parent.Tree()
+	// Get all files in tree
+	tree, err := commit.Tree()
 	if err != nil {
-		log.WithError(err).Error("Failed to get parent commit tree")
+		log.WithError(err).Error("Failed to get tree object")
 		return changes, stats, diffSummary, err
 	}
 
-	// Get current commit tree
-	currentTree, err := commit.Tree()
-	if err != nil {
-		log.WithError(err).Error("Failed to get current commit tree")
-		return changes, stats, diffSummary, err
+	// Collect file entries without reading blobs, contents are read by the workers
+	type fileEntry struct {
+		name  string
+		entry object.TreeEntry
+	}
+	entries := make([]fileEntry, 0)
+	filter := a.newPathFilter(tree)
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to iterate files")
+			return changes, stats, diffSummary, err
+		}
+		if (entry.Mode.IsFile() || entry.Mode == filemode.Submodule) && filter.keepPath(name) {
+			entries = append(entries, fileEntry{name: name, entry: entry})
+		}
 	}
 
-	log.Debug("Started generating patch")
+	workers := workerCount(cfg.Workers, len(entries))
+	lfsStore := a.lfsObjectStore(repo)
 
-	// Compare two trees
-	patch, err := parentTree.Patch(currentTree)
+	log.WithFields(logger.Fields{
+		"files":   len(entries),
+		"workers": workers,
+	}).Debug("Collected initial commit files")
+
+	results := make([]fileChangeResult, len(entries))
+	err = runWorkers(ctx, len(entries), workers, func() (*workerTrees, error) {
+		return newWorkerTrees(repo, plumbing.ZeroHash, tree.Hash)
+	}, func(w *workerTrees, i int) error {
+		if entries[i].entry.Mode == filemode.Submodule {
+			change := rootChange(entries[i].name, entries[i].entry)
+			results[i] = a.processSubmoduleChange(i, change, log)
+			setChangeKind(&results[i].change, change)
+			return nil
+		}
+		blob, err := object.GetBlob(w.storer, entries[i].entry.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to read blob of %s: %w", entries[i].name, err)
+		}
+		pointer, err := readLFSPointer(w.storer, entries[i].entry)
+		if err != nil {
+			return fmt.Errorf("failed to read blob of %s: %w", entries[i].name, err)
+		}
+		results[i] = a.processRootFile(entries[i].name, entries[i].entry, blob, pointer, lfsStore, w.toAttrs, log)
+		return nil
+	})
 	if err != nil {
-		log.WithError(err).Error("Failed to generate patch")
+		log.WithError(err).Error("Failed to iterate files")
 		return changes, stats, diffSummary, err
 	}
 
-	log.WithFields(logger.Fields{
-		"patch_files": len(patch.FilePatches()),
-	}).Debug("Patch generation completed")
+	a.applyReportBudget(results)
 
-	// Process each file change
-	fullDiff := ""
+	var fullDiff strings.Builder
 	totalDiffSize := 0
+	for _, result := range results {
+		changes = append(changes, result.change)
+		stats.Add(result.stats)
+		totalDiffSize += len(result.change.DiffContent)
+		if result.diffTooLarge {
+			diffSummary.DiffTooLarge = true
+		}
+		if result.change.Truncated {
+			diffSummary.TruncatedFiles++
+		}
+		if cfg.IncludeFullDiff.Value() && result.change.DiffContent != "" {
+			fullDiff.WriteString(result.change.DiffContent + "\n\n")
+		}
+	}
+
+	stats.TotalFiles = len(changes)
+	stats.FilteredFiles = filter.filteredFiles()
+	diffSummary.TotalDiffSize = totalDiffSize
+
+	if cfg.IncludeFullDiff.Value() {
+		diffSummary.FullDiff = fullDiff.String()
+	}
 
-	for i, filePatch := range patch.FilePatches() {
-		fromFile, toFile := filePatch.Files()
+	log.WithFields(logger.Fields{
+		"total_files":     len(changes),
+		"filtered_files":  stats.FilteredFiles,
+		"total_diff_size": totalDiffSize,
+	}).Debug("Initial commit file statistics completed")
+
+	return changes, stats, diffSummary, nil
+}
 
-		change := types.ChangeInfo{}
-		var filePath string
-		var fromPath, toPath string
+// processRootFile builds change information of a file added by the root commit.
+// In summary mode only the configured number of leading lines is included.
+// The content of an LFS pointer file is read from the LFS object store if available.
+func (a *Analyzer) processRootFile(name string, entry object.TreeEntry, blob *object.Blob, pointer *lfsPointer, store *lfsStore, attrs *attributeResolver, log logger.Logger) fileChangeResult {
+	cfg := a.cfg
+	result := fileChangeResult{ok: true}
+	result.stats.AddFiles++
+
+	change := types.ChangeInfo{
+		Action:    "add",
+		Filepath:  name,
+		Extension: types.GetFileExtension(name),
+		FileSize:  blob.Size,
+		BlobHash:  entry.Hash.String(),
+		NewMode:   fileModeString(entry.Mode),
+	}
+	if entry.Mode == filemode.Symlink {
+		change.Kind = types.KindSymlink
+	}
+	if pointer != nil {
+		change.LFS = lfsChangeInfo(nil, pointer)
+		result.stats.LFSFiles++
+	}
 
-		// Get file path
-		if fromFile != nil {
-			fromPath = fromFile.Path()
+	maxLines := -1
+	if cfg.RootCommit.Mode != config.RootCommitFull {
+		maxLines = cfg.RootCommit.SampleLines
+	}
+
+	// The gitattributes diff attribute takes precedence over content detection
+	var content blobContent
+	var err error
+	fileAttrs := attrs.attributes(name)
+	change.Attributes = attributeValues(fileAttrs)
+	forcedBinary, forced := diffAttribute(fileAttrs)
+	resolved := false
+	scan := a.secrets.File(name)
+	var visit func(string)
+	if a.secrets.Enabled() {
+		lineNo := 0
+		visit = func(line string) {
+			lineNo++
+			scan.Added(lineNo, line)
 		}
-		if toFile != nil {
-			toPath = toFile.Path()
+	}
+	switch {
+	case forced && forcedBinary:
+		content.isBinary = true
+	case pointer != nil:
+		content, resolved, err = readLFSContent(store, pointer, maxLines, !forced, visit)
+		change.LFS.Resolved = resolved
+	default:
+		content, err = readBlobContent(blob, maxLines, !forced, visit)
+	}
+
+	// The sampled lines form a single hunk
+	parts := &diffParts{}
+	switch {
+	case err != nil:
+		log.WithFields(logger.Fields{
+			"file":  name,
+			"error": err.Error(),
+		}).Warn("Failed to read file content")
+		parts.header = fmt.Sprintf("// Unable to read file content: %v\n", err)
+	case pointer != nil && !resolved && !content.isBinary:
+		parts.header = fmt.Sprintf("LFS objects /dev/null and b/%s differ\n", name)
+	case content.isBinary:
+		change.IsBinary = true
+		result.stats.BinaryFiles++
+		parts.header = fmt.Sprintf("Binary files /dev/null and b/%s differ\n", name)
+	default:
+		change.Additions = content.lines
+		result.stats.TotalAdditions += content.lines
+		change.Secrets = scan.Findings()
+		result.stats.SecretFindings += len(change.Secrets)
+		if pointer == nil && a.analyzesTemplate(name) {
+			change.Template = rootTemplate(name, blob, log)
 		}
 
-		// Determine change type and file path
-		if fromFile == nil && toFile != nil {
-			// Added file
-			change.Action = "add"
+		if content.sampleLines > 0 {
+			var header strings.Builder
+			writeDiffHeader(&header, "", name, filemode.Empty, entry.Mode)
+			header.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", content.sampleLines))
+			parts.header = header.String()
+
+			var hunk []string
+			for _, line := range strings.SplitAfter(content.sample, "\n") {
+				if line != "" {
+					hunk = append(hunk, "+"+scan.Redact(strings.TrimSuffix(line, "\n"))+"\n")
+				}
+			}
+			parts.hunks = [][]string{hunk}
+		}
+	}
+
+	result.change = change
+	a.setDiff(&result, parts)
+	return result
+}
+
+// setChangeAction sets change type and paths from the paths before and after
+// the change, counts the change type and returns the file path
+func setChangeAction(change *types.ChangeInfo, stats *types.StatsInfo, fromPath, toPath string, i int, log logger.Logger) string {
+	var filePath string
+
+	// Determine change type and file path
+	if fromPath == "" && toPath != "" {
+		// Added file
+		change.Action = "add"
+		filePath = toPath
+		change.Filepath = filePath
+		stats.AddFiles++
+	} else if fromPath != "" && toPath == "" {
+		// Deleted file
+		change.Action = "delete"
+		filePath = fromPath
+		change.Filepath = filePath
+		stats.DeleteFiles++
+	} else if fromPath != "" && toPath != "" {
+		// Modified, renamed or copied
+		if fromPath != toPath {
+			// Renamed
+			change.Action = "rename"
+			change.OldPath = fromPath
+			change.NewPath = toPath
+			change.Filepath = toPath
 			filePath = toPath
-			change.Filepath = filePath
-			stats.AddFiles++
-		} else if fromFile != nil && toFile == nil {
-			// Deleted file
-			change.Action = "delete"
+			stats.RenameFiles++
+
+			log.WithFields(logger.Fields{
+				"file_index": i,
+				"old_path":   fromPath,
+				"new_path":   toPath,
+			}).Debug("Detected file rename")
+		} else {
+			// Modified
+			change.Action = "modify"
 			filePath = fromPath
 			change.Filepath = filePath
-			stats.DeleteFiles++
-		} else if fromFile != nil && toFile != nil {
-			// Modified, renamed or copied
-			if fromPath != toPath {
-				// Renamed
-				change.Action = "rename"
-				change.OldPath = fromPath
-				change.NewPath = toPath
-				change.Filepath = toPath
-				filePath = toPath
-				stats.RenameFiles++
-
-				log.WithFields(logger.Fields{
-					"file_index": i,
-					"old_path":   fromPath,
-					"new_path":   toPath,
-				}).Debug("Detected file rename")
-			} else {
-				// Modified
-				change.Action = "modify"
-				filePath = fromPath
-				change.Filepath = filePath
-				stats.ModifyFiles++
-			}
+			stats.ModifyFiles++
 		}
+	}
 
-		// Get file extension
-		change.Extension = types.GetFileExtension(filePath)
-
-		// Count line changes and generate diff content
-		additions := 0
-		deletions := 0
-
-		// Generate diff content
-		var diffContentBuilder strings.Builder
-
-		// Write diff header
-		if fromFile == nil && toFile != nil {
-			// Added file
-			diffContentBuilder.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", toPath, toPath))
-			diffContentBuilder.WriteString(fmt.Sprintf("new file mode 100644\n"))
-			diffContentBuilder.WriteString(fmt.Sprintf("--- /dev/null\n"))
-			diffContentBuilder.WriteString(fmt.Sprintf("+++ b/%s\n", toPath))
-		} else if fromFile != nil && toFile == nil {
-			// Deleted file
-			diffContentBuilder.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", fromPath, fromPath))
-			diffContentBuilder.WriteString(fmt.Sprintf("deleted file mode 100644\n"))
-			diffContentBuilder.WriteString(fmt.Sprintf("--- a/%s\n", fromPath))
-			diffContentBuilder.WriteString(fmt.Sprintf("+++ /dev/null\n"))
-		} else if fromFile != nil && toFile != nil {
-			if fromPath == toPath {
-				// Modified file
-				diffContentBuilder.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", fromPath, toPath))
-				diffContentBuilder.WriteString(fmt.Sprintf("--- a/%s\n", fromPath))
-				diffContentBuilder.WriteString(fmt.Sprintf("+++ b/%s\n", toPath))
-			} else {
-				// Renamed file
-				diffContentBuilder.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", fromPath, toPath))
-				diffContentBuilder.WriteString(fmt.Sprintf("rename from %s\n", fromPath))
-				diffContentBuilder.WriteString(fmt.Sprintf("rename to %s\n", toPath))
-			}
+	return filePath
+}
+
+// detectBinaryChange decides whether a tree change is binary. The gitattributes
+// diff attribute of the path decides if set (binary and -diff mean binary),
+// otherwise the content of the blobs before and after the change.
+func detectBinaryChange(w *workerTrees, change *object.Change, attrs map[string]gitattributes.Attribute) (bool, error) {
+	if binary, ok := diffAttribute(attrs); ok {
+		return binary, nil
+	}
+
+	for _, entry := range []object.ChangeEntry{change.From, change.To} {
+		if entry.Name == "" || !entry.TreeEntry.Mode.IsFile() {
+			continue
+		}
+		head, err := readBlobHead(w.storer, entry.TreeEntry.Hash)
+		if err != nil {
+			return false, err
+		}
+		if isBinaryContent(head) {
+			return true, nil
 		}
+	}
+	return false, nil
+}
 
-		// Process each chunk
-		for _, chunk := range filePatch.Chunks() {
-			content := chunk.Content()
-			lines := strings.Split(content, "\n")
+// processBinaryChange builds change information of a binary file change,
+// reporting sizes and blob hashes before and after the change instead of a diff
+func (a *Analyzer) processBinaryChange(i int, treeChange *object.Change, w *workerTrees, log logger.Logger) fileChangeResult {
+	result := fileChangeResult{ok: true}
+	fromPath, toPath := treeChange.From.Name, treeChange.To.Name
+
+	change := types.ChangeInfo{IsBinary: true}
+	filePath := setChangeAction(&change, &result.stats, fromPath, toPath, i, log)
+	change.Extension = types.GetFileExtension(filePath)
+	result.stats.BinaryFiles++
+
+	fromName, toName := "/dev/null", "/dev/null"
+	if fromPath != "" {
+		fromName = "a/" + fromPath
+		change.OldBlobHash = treeChange.From.TreeEntry.Hash.String()
+		change.OldFileSize = blobSize(w.storer, treeChange.From.TreeEntry.Hash)
+	}
+	if toPath != "" {
+		toName = "b/" + toPath
+		change.BlobHash = treeChange.To.TreeEntry.Hash.String()
+		change.FileSize = blobSize(w.storer, treeChange.To.TreeEntry.Hash)
+	}
 
-			// Remove trailing empty string (if exists)
-			if len(lines) > 0 && lines[len(lines)-1] == "" {
-				lines = lines[:len(lines)-1]
-			}
+	change.DiffContent = fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+	result.diffSize = len(change.DiffContent)
+	result.change = change
 
-			lineCount := len(lines)
+	log.WithFields(logger.Fields{
+		"file_index": i,
+		"file":       filePath,
+		"old_size":   change.OldFileSize,
+		"new_size":   change.FileSize,
+	}).Debug("Detected binary file change")
 
-			switch chunk.Type() {
-			case diff.Add:
-				// Added lines
-				additions += lineCount
-				stats.TotalAdditions += lineCount
+	return result
+}
 
-				// Add added lines to diff
-				for _, line := range lines {
-					if line != "" {
-						diffContentBuilder.WriteString(fmt.Sprintf("+%s\n", line))
-					}
-				}
+// blobSize returns the size of a blob, 0 if it cannot be read
+func blobSize(s storer.EncodedObjectStorer, hash plumbing.Hash) int64 {
+	obj, err := s.EncodedObject(plumbing.BlobObject, hash)
+	if err != nil {
+		return 0
+	}
+	return obj.Size()
+}
 
-			case diff.Delete:
-				// Deleted lines
-				deletions += lineCount
-				stats.TotalDeletions += lineCount
+// processSubmoduleChange builds change information of a submodule change. Submodule
+// entries point to commits of another repository, so like git the diff shows the
+// submodule commits instead of content.
+func (a *Analyzer) processSubmoduleChange(i int, treeChange *object.Change, log logger.Logger) fileChangeResult {
+	result := fileChangeResult{ok: true}
+	from, to := treeChange.From, treeChange.To
+
+	change := types.ChangeInfo{Submodule: &types.SubmoduleChange{}}
+	filePath := setChangeAction(&change, &result.stats, from.Name, to.Name, i, log)
+
+	// A file replaced by a submodule or the other way round only reports the submodule side
+	var lines strings.Builder
+	if from.Name != "" && from.TreeEntry.Mode == filemode.Submodule {
+		change.Submodule.OldCommit = from.TreeEntry.Hash.String()
+		change.Deletions = 1
+		lines.WriteString(fmt.Sprintf("-Subproject commit %s\n", change.Submodule.OldCommit))
+	}
+	if to.Name != "" && to.TreeEntry.Mode == filemode.Submodule {
+		change.Submodule.NewCommit = to.TreeEntry.Hash.String()
+		change.Additions = 1
+		lines.WriteString(fmt.Sprintf("+Subproject commit %s\n", change.Submodule.NewCommit))
+	}
+	result.stats.TotalAdditions += change.Additions
+	result.stats.TotalDeletions += change.Deletions
 
-				// Add deleted lines to diff
-				for _, line := range lines {
-					if line != "" {
-						diffContentBuilder.WriteString(fmt.Sprintf("-%s\n", line))
-					}
-				}
-			}
+	var diffContentBuilder strings.Builder
+	writeDiffHeader(&diffContentBuilder, from.Name, to.Name, from.TreeEntry.Mode, to.TreeEntry.Mode)
+	diffContentBuilder.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(change.Deletions), hunkRange(change.Additions)))
+	diffContentBuilder.WriteString(lines.String())
+
+	change.DiffContent = diffContentBuilder.String()
+	if a.cfg.ParseDiff.Value() {
+		change.AdditionsList, change.DeletionsList = a.parseDiffContent(change.DiffContent)
+	}
+
+	result.diffSize = len(change.DiffContent)
+	result.change = change
+
+	log.WithFields(logger.Fields{
+		"file_index": i,
+		"file":       filePath,
+		"old_commit": change.Submodule.OldCommit,
+		"new_commit": change.Submodule.NewCommit,
+	}).Debug("Detected submodule change")
+
+	return result
+}
+
+// rootChange returns the tree change adding an entry of the root commit
+func rootChange(name string, entry object.TreeEntry) *object.Change {
+	return &object.Change{To: object.ChangeEntry{Name: name, TreeEntry: entry}}
+}
+
+// hunkRange formats a hunk range of a single line hunk, 0,0 for no line like git
+func hunkRange(lines int) string {
+	if lines == 0 {
+		return "0,0"
+	}
+	return "1"
+}
+
+// setChangeKind sets the file modes before and after a tree change and its
+// special change kind. A change involving a submodule or a symlink is reported as
+// such, otherwise a change of the file mode of an existing file is a mode change.
+func setChangeKind(change *types.ChangeInfo, treeChange *object.Change) {
+	from, to := treeChange.From, treeChange.To
+	if from.Name != "" {
+		change.OldMode = fileModeString(from.TreeEntry.Mode)
+	}
+	if to.Name != "" {
+		change.NewMode = fileModeString(to.TreeEntry.Mode)
+	}
+
+	switch {
+	case from.TreeEntry.Mode == filemode.Submodule || to.TreeEntry.Mode == filemode.Submodule:
+		change.Kind = types.KindSubmodule
+	case from.TreeEntry.Mode == filemode.Symlink || to.TreeEntry.Mode == filemode.Symlink:
+		change.Kind = types.KindSymlink
+	case change.OldMode != "" && change.NewMode != "" && change.OldMode != change.NewMode:
+		change.Kind = types.KindModeChange
+	}
+}
+
+// fileModeString formats a file mode like git does, e.g. 100644
+func fileModeString(mode filemode.FileMode) string {
+	return fmt.Sprintf("%06o", uint32(mode))
+}
+
+// writeDiffHeader writes a git style diff header with the file modes, an empty
+// path means the file does not exist on that side of the change
+func writeDiffHeader(b *strings.Builder, fromPath, toPath string, fromMode, toMode filemode.FileMode) {
+	switch {
+	case fromPath == "":
+		// Added file
+		b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", toPath, toPath))
+		b.WriteString(fmt.Sprintf("new file mode %s\n", fileModeString(toMode)))
+		b.WriteString("--- /dev/null\n")
+		b.WriteString(fmt.Sprintf("+++ b/%s\n", toPath))
+	case toPath == "":
+		// Deleted file
+		b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", fromPath, fromPath))
+		b.WriteString(fmt.Sprintf("deleted file mode %s\n", fileModeString(fromMode)))
+		b.WriteString(fmt.Sprintf("--- a/%s\n", fromPath))
+		b.WriteString("+++ /dev/null\n")
+	default:
+		b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", fromPath, toPath))
+		if fromMode != toMode {
+			b.WriteString(fmt.Sprintf("old mode %s\n", fileModeString(fromMode)))
+			b.WriteString(fmt.Sprintf("new mode %s\n", fileModeString(toMode)))
+		}
+		if fromPath == toPath {
+			// Modified file
+			b.WriteString(fmt.Sprintf("--- a/%s\n", fromPath))
+			b.WriteString(fmt.Sprintf("+++ b/%s\n", toPath))
+		} else {
+			// Renamed file
+			b.WriteString(fmt.Sprintf("rename from %s\n", fromPath))
+			b.WriteString(fmt.Sprintf("rename to %s\n", toPath))
 		}
+	}
+}
 
-		change.Additions = additions
-		change.Deletions = deletions
+// processFilePatch builds change information of a single file patch
+func (a *Analyzer) processFilePatch(i int, filePatch diff.FilePatch, currentTree *object.Tree, log logger.Logger) fileChangeResult {
+	result := fileChangeResult{ok: true}
+	stats := &result.stats
+	fromFile, toFile := filePatch.Files()
 
-		// Get detailed diff content
-		if toFile != nil {
-			// Try to get file size
-			file, err := currentTree.File(filePath)
-			if err == nil {
-				change.FileSize = file.Size
-			}
+	change := types.ChangeInfo{}
+	var filePath string
+	var fromPath, toPath string
 
-			// Check if file is likely binary
-			change.IsBinary = types.IsLikelyBinaryFile(filePath)
-
-			// Get generated diff content
-			fileDiff := diffContentBuilder.String()
-			change.DiffContent = fileDiff
-
-			if change.IsBinary {
-				stats.BinaryFiles++
-			} else {
-				// Parse diff content
-				if cfg.ParseDiff {
-					additions, deletions := parseDiffContent(fileDiff)
-					change.AdditionsList = additions
-					change.DeletionsList = deletions
-				}
-			}
+	// Get file path
+	if fromFile != nil {
+		fromPath = fromFile.Path()
+	}
+	if toFile != nil {
+		toPath = toFile.Path()
+	}
 
-			// Check diff size
-			diffSize := len(fileDiff)
-			totalDiffSize += diffSize
+	// Determine change type and file path
+	filePath = setChangeAction(&change, stats, fromPath, toPath, i, log)
 
-			if diffSize > cfg.MaxDiffSize {
-				change.DiffContent = fmt.Sprintf("// Diff content too large (%d bytes), truncated", diffSize)
-				diffSummary.DiffTooLarge = true
-			}
+	// Get file extension
+	change.Extension = types.GetFileExtension(filePath)
+
+	// Count line changes and collect the changed lines of each hunk,
+	// unchanged lines separate hunks
+	additions := 0
+	deletions := 0
+	parts := &diffParts{}
+	var hunk []string
+
+	// Added lines are scanned for secrets, newLine is their line number in the new file
+	scan := a.secrets.File(filePath)
+	newLine := 1
+
+	// Template and severity tracked files are parsed from the content on both sides of the change
+	analyzeTemplate := a.analyzesTemplate(filePath)
+	trackSeverity := a.severity.Tracks(filePath)
+	var before, after strings.Builder
 
-			// If full file diff is needed, add to fullDiff
-			if cfg.IncludeFullDiff {
-				fullDiff += fileDiff + "\n\n"
+	// Write diff header
+	fromMode, toMode := filemode.Empty, filemode.Empty
+	if fromFile != nil {
+		fromMode = fromFile.Mode()
+	}
+	if toFile != nil {
+		toMode = toFile.Mode()
+	}
+	var header strings.Builder
+	writeDiffHeader(&header, fromPath, toPath, fromMode, toMode)
+	parts.header = header.String()
+
+	// Process each chunk
+	for _, chunk := range filePatch.Chunks() {
+		content := chunk.Content()
+		lines := strings.Split(content, "\n")
+
+		// Remove trailing empty string (if exists)
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+
+		lineCount := len(lines)
+
+		if analyzeTemplate || trackSeverity {
+			if chunk.Type() != diff.Add {
+				before.WriteString(content)
+			}
+			if chunk.Type() != diff.Delete {
+				after.WriteString(content)
 			}
-		} else if fromFile != nil {
-			// Deleted file case
-			change.IsBinary = types.IsLikelyBinaryFile(filePath)
-			if change.IsBinary {
-				stats.BinaryFiles++
-			} else {
-				// Parse diff content
-				if cfg.ParseDiff {
-					fileDiff := diffContentBuilder.String()
-					_, deletions := parseDiffContent(fileDiff)
-					change.DeletionsList = deletions
+		}
+
+		switch chunk.Type() {
+		case diff.Add:
+			// Added lines
+			additions += lineCount
+			stats.TotalAdditions += lineCount
+
+			// Add added lines to diff
+			for _, line := range lines {
+				line = scan.Added(newLine, line)
+				newLine++
+				if line != "" {
+					hunk = append(hunk, fmt.Sprintf("+%s\n", line))
 				}
 			}
 
-			// Get generated diff content
-			fileDiff := diffContentBuilder.String()
-			change.DiffContent = fileDiff
+		case diff.Delete:
+			// Deleted lines
+			deletions += lineCount
+			stats.TotalDeletions += lineCount
 
-			diffSize := len(fileDiff)
-			totalDiffSize += diffSize
+			// Add deleted lines to diff
+			for _, line := range lines {
+				line = scan.Redact(line)
+				if line != "" {
+					hunk = append(hunk, fmt.Sprintf("-%s\n", line))
+				}
+			}
 
-			if cfg.IncludeFullDiff {
-				fullDiff += fileDiff + "\n\n"
+		case diff.Equal:
+			newLine += lineCount
+			if len(hunk) > 0 {
+				parts.hunks = append(parts.hunks, hunk)
+				hunk = nil
 			}
 		}
+	}
+	if len(hunk) > 0 {
+		parts.hunks = append(parts.hunks, hunk)
+	}
 
-		changes = append(changes, change)
+	change.Additions = additions
+	change.Deletions = deletions
+	change.Secrets = scan.Findings()
+	stats.SecretFindings += len(change.Secrets)
+	if analyzeTemplate {
+		change.Template = compareTemplates(filePath, []byte(before.String()), []byte(after.String()), log)
+	}
+	if trackSeverity && fromPath != "" && toPath != "" {
+		change.Severity = a.severity.Compare(filePath, []byte(before.String()), []byte(after.String()))
+		if change.Severity != nil {
+			switch change.Severity.Direction {
+			case types.SeverityEscalation:
+				stats.Escalations++
+			case types.SeverityDeescalation:
+				stats.Deescalations++
+			}
+		}
+	}
 
-		// Log detailed change information
-		if log.GetLevel() >= logrus.DebugLevel {
-			log.WithFields(logger.Fields{
-				"file_index":      i,
-				"file":            filePath,
-				"action":          change.Action,
-				"additions":       change.Additions,
-				"deletions":       change.Deletions,
-				"diff_size":       len(change.DiffContent),
-				"is_binary":       change.IsBinary,
-				"additions_count": len(change.AdditionsList),
-				"deletions_count": len(change.DeletionsList),
-			}).Debug("File change details")
+	// Try to get file size
+	if toFile != nil {
+		file, err := currentTree.File(filePath)
+		if err == nil {
+			change.FileSize = file.Size
 		}
 	}
 
-	stats.TotalFiles = len(changes)
-	diffSummary.TotalDiffSize = totalDiffSize
+	result.change = change
+	a.setDiff(&result, parts)
 
-	if cfg.IncludeFullDiff {
-		diffSummary.FullDiff = fullDiff
+	// Log detailed change information
+	if log.GetLevel() >= logrus.DebugLevel {
+		log.WithFields(logger.Fields{
+			"file_index":      i,
+			"file":            filePath,
+			"action":          change.Action,
+			"additions":       change.Additions,
+			"deletions":       change.Deletions,
+			"diff_size":       result.diffSize,
+			"is_binary":       change.IsBinary,
+			"truncated":       result.change.Truncated,
+			"additions_count": len(result.change.AdditionsList),
+			"deletions_count": len(result.change.DeletionsList),
+		}).Debug("File change details")
 	}
 
-	log.WithFields(logger.Fields{
-		"total_files":     stats.TotalFiles,
-		"add_files":       stats.AddFiles,
-		"delete_files":    stats.DeleteFiles,
-		"modify_files":    stats.ModifyFiles,
-		"rename_files":    stats.RenameFiles,
-		"binary_files":    stats.BinaryFiles,
-		"total_additions": stats.TotalAdditions,
-		"total_deletions": stats.TotalDeletions,
-		"total_diff_size": diffSummary.TotalDiffSize,
-		"diff_too_large":  diffSummary.DiffTooLarge,
-	}).Info("Change information statistics completed")
-
-	return changes, stats, diffSummary, nil
+	return result
 }
 
 // parseDiffContent parses diff string, extracts added and deleted lines
-func parseDiffContent(diffContent string) ([]types.LineChange, []types.LineChange) {
+func (a *Analyzer) parseDiffContent(diffContent string) ([]types.LineChange, []types.LineChange) {
 	var additions []types.LineChange
 	var deletions []types.LineChange
 
@@ -751,6 +1598,8 @@ func parseDiffContent(diffContent string) ([]types.LineChange, []types.LineChang
 				strings.HasPrefix(line, "+++ ") ||
 				strings.HasPrefix(line, "new file mode") ||
 				strings.HasPrefix(line, "deleted file mode") ||
+				strings.HasPrefix(line, "old mode") ||
+				strings.HasPrefix(line, "new mode") ||
 				strings.HasPrefix(line, "rename from") ||
 				strings.HasPrefix(line, "rename to") {
 				continue
@@ -806,6 +1655,8 @@ func parseDiffContent(diffContent string) ([]types.LineChange, []types.LineChang
 				strings.HasPrefix(line, "+++ ") ||
 				strings.HasPrefix(line, "new file mode") ||
 				strings.HasPrefix(line, "deleted file mode") ||
+				strings.HasPrefix(line, "old mode") ||
+				strings.HasPrefix(line, "new mode") ||
 				strings.HasPrefix(line, "rename from") ||
 				strings.HasPrefix(line, "rename to") {
 				skipHeader = true
@@ -841,7 +1692,7 @@ func parseDiffContent(diffContent string) ([]types.LineChange, []types.LineChang
 	}
 
 	// Log parsing result
-	log.WithFields(logger.Fields{
+	a.log.WithFields(logger.Fields{
 		"additions_count": len(additions),
 		"deletions_count": len(deletions),
 	}).Debug("Diff content parsing completed")
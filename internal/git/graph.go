@@ -0,0 +1,408 @@
+package git
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// graphNode commit of the commit graph
+type graphNode struct {
+	parents    []plumbing.Hash // Parent commit hashes
+	generation uint64          // 1 for root commits, otherwise 1 + highest generation of the parents
+	author     string          // Lower cased author email, empty for missing commits and authors without email
+}
+
+// maxCachedGraphs maximum number of repository commit graphs kept by an Analyzer
+const maxCachedGraphs = 8
+
+// graphCache commit graphs of the repositories an Analyzer reads, shared by all of
+// its analyses. The least recently used graph is dropped when more than
+// maxCachedGraphs repositories are read.
+type graphCache struct {
+	mu     sync.Mutex
+	graphs map[string]*cachedGraph
+	clock  uint64 // Increased on every use, orders graphs by last use
+}
+
+// cachedGraph commit graph of a repository with its last use
+type cachedGraph struct {
+	graph    *commitGraph
+	lastUsed uint64
+}
+
+// newGraphCache creates an empty graph cache
+func newGraphCache() *graphCache {
+	return &graphCache{graphs: make(map[string]*cachedGraph)}
+}
+
+// get returns the commit graph of a repository, creating it on first use
+func (c *graphCache) get(repo *git.Repository) *commitGraph {
+	key := graphKey(repo)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock++
+
+	if cached, ok := c.graphs[key]; ok {
+		cached.lastUsed = c.clock
+		return cached.graph
+	}
+
+	if len(c.graphs) >= maxCachedGraphs {
+		oldest := ""
+		for k, cached := range c.graphs {
+			if oldest == "" || cached.lastUsed < c.graphs[oldest].lastUsed {
+				oldest = k
+			}
+		}
+		delete(c.graphs, oldest)
+	}
+
+	g := newCommitGraph()
+	c.graphs[key] = &cachedGraph{graph: g, lastUsed: c.clock}
+	return g
+}
+
+// graphKey identifies the history of a repository by its storage location and its
+// shallow commits, so a graph holding placeholders for the missing ancestors of a
+// shallow clone is not used once the clone is deepened
+func graphKey(repo *git.Repository) string {
+	var b strings.Builder
+	if fsStorage, ok := repo.Storer.(*filesystem.Storage); ok {
+		root := fsStorage.Filesystem().Root()
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		b.WriteString(root)
+	} else {
+		fmt.Fprintf(&b, "%p", repo.Storer)
+	}
+
+	if shallow, err := repo.Storer.Shallow(); err == nil {
+		for _, hash := range shallow {
+			b.WriteString(":" + hash.String())
+		}
+	}
+	return b.String()
+}
+
+// commitGraph caches parents and generation numbers of the commits of one repository.
+// Commits are immutable, so cached nodes stay valid for the lifetime of the repository
+// history, only missing ancestors of shallow clones are stored as placeholder roots.
+type commitGraph struct {
+	mu      sync.RWMutex
+	nodes   map[plumbing.Hash]*graphNode
+	authors map[string][]plumbing.Hash // Cached commits by lower cased author email
+}
+
+// newCommitGraph creates an empty commit graph
+func newCommitGraph() *commitGraph {
+	return &commitGraph{
+		nodes:   make(map[plumbing.Hash]*graphNode),
+		authors: make(map[string][]plumbing.Hash),
+	}
+}
+
+// lookup returns the cached node of a commit
+func (g *commitGraph) lookup(hash plumbing.Hash) (*graphNode, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	n, ok := g.nodes[hash]
+	return n, ok
+}
+
+// store caches the node of a commit
+func (g *commitGraph) store(hash plumbing.Hash, n *graphNode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes[hash] = n
+	if n.author != "" {
+		g.authors[n.author] = append(g.authors[n.author], hash)
+	}
+}
+
+// node returns the node of a commit, loading the commit and all its uncached ancestors.
+// Ancestors missing from the repository (shallow clones) are treated as root commits.
+func (g *commitGraph) node(repo *git.Repository, hash plumbing.Hash) (*graphNode, error) {
+	if n, ok := g.lookup(hash); ok {
+		return n, nil
+	}
+
+	// Depth first, a commit is stored once all of its parents are stored
+	pending := make(map[plumbing.Hash]*graphNode)
+	stack := []plumbing.Hash{hash}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		if _, ok := g.lookup(h); ok {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		n, loaded := pending[h]
+		if !loaded {
+			commit, err := repo.CommitObject(h)
+			switch {
+			case err == nil:
+				n = &graphNode{parents: commit.ParentHashes, author: strings.ToLower(strings.TrimSpace(commit.Author.Email))}
+			case h != hash && errors.Is(err, plumbing.ErrObjectNotFound):
+				// Missing ancestor of a shallow clone
+				n = &graphNode{}
+			default:
+				return nil, fmt.Errorf("failed to get commit object %s: %w", h, err)
+			}
+			pending[h] = n
+		}
+
+		ready := true
+		var generation uint64
+		for _, p := range n.parents {
+			pn, ok := g.lookup(p)
+			if !ok {
+				ready = false
+				stack = append(stack, p)
+				continue
+			}
+			generation = max(generation, pn.generation)
+		}
+		if !ready {
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		delete(pending, h)
+		n.generation = generation + 1
+		g.store(h, n)
+	}
+
+	n, _ := g.lookup(hash)
+	return n, nil
+}
+
+// contains reports whether target is reachable from tip.
+// memo caches results for the same target across calls.
+func (g *commitGraph) contains(repo *git.Repository, tip, target plumbing.Hash, memo map[plumbing.Hash]bool) (bool, error) {
+	targetNode, err := g.node(repo, target)
+	if err != nil {
+		return false, err
+	}
+	if _, err := g.node(repo, tip); err != nil {
+		return false, err
+	}
+	return g.reaches(tip, target, targetNode.generation, memo), nil
+}
+
+// reaches reports whether target is reachable from h, all ancestors of h must be loaded.
+// Commits with a generation not above the target generation cannot reach the target.
+// memo records commits known not to reach the target. The walk is iterative, so deep
+// histories do not grow the goroutine stack.
+func (g *commitGraph) reaches(h, target plumbing.Hash, targetGeneration uint64, memo map[plumbing.Hash]bool) bool {
+	if h == target {
+		return true
+	}
+	if r, ok := memo[h]; ok {
+		return r
+	}
+
+	// Depth first with the path from h on the stack, so a found target marks the
+	// whole path as reaching it
+	type frame struct {
+		hash plumbing.Hash
+		next int // Index of the next parent to visit
+	}
+	memo[h] = false
+	stack := []frame{{hash: h}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		n, _ := g.lookup(top.hash)
+		if n.generation <= targetGeneration || top.next == len(n.parents) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		p := n.parents[top.next]
+		top.next++
+		if r, ok := memo[p]; p == target || (ok && r) {
+			for _, f := range stack {
+				memo[f.hash] = true
+			}
+			return true
+		} else if ok {
+			continue
+		}
+		memo[p] = false
+		stack = append(stack, frame{hash: p})
+	}
+	return false
+}
+
+// walkAncestors calls fn for the commit and its ancestors, highest generation first,
+// until fn returns false
+func (g *commitGraph) walkAncestors(repo *git.Repository, hash plumbing.Hash, fn func(h plumbing.Hash, generation uint64) bool) error {
+	if _, err := g.node(repo, hash); err != nil {
+		return err
+	}
+
+	visited := map[plumbing.Hash]bool{hash: true}
+	queue := &generationQueue{}
+	g.push(queue, hash)
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		if !fn(item.hash, item.generation) {
+			return nil
+		}
+
+		n, _ := g.lookup(item.hash)
+		for _, p := range n.parents {
+			if !visited[p] {
+				visited[p] = true
+				g.push(queue, p)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasEarlierAuthor reports whether an ancestor of a commit has the same author email.
+// Only ancestors count, commits of the same author on other branches do not.
+// Loading a commit caches all its ancestors, so the cached commits of the email
+// hold every candidate; they are checked nearest generation first.
+func (g *commitGraph) hasEarlierAuthor(repo *git.Repository, hash plumbing.Hash) (bool, error) {
+	n, err := g.node(repo, hash)
+	if err != nil {
+		return false, err
+	}
+
+	type candidate struct {
+		hash       plumbing.Hash
+		generation uint64
+	}
+	var candidates []candidate
+	g.mu.RLock()
+	for _, h := range g.authors[n.author] {
+		if cn := g.nodes[h]; cn.generation < n.generation {
+			candidates = append(candidates, candidate{h, cn.generation})
+		}
+	}
+	g.mu.RUnlock()
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].generation > candidates[j].generation })
+
+	for _, c := range candidates {
+		if g.reaches(hash, c.hash, c.generation, make(map[plumbing.Hash]bool)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// countBetween counts commits reachable from include but not from exclude,
+// like git rev-list --count exclude..include
+func (g *commitGraph) countBetween(repo *git.Repository, include, exclude plumbing.Hash) (int, error) {
+	if _, err := g.node(repo, include); err != nil {
+		return 0, err
+	}
+	if _, err := g.node(repo, exclude); err != nil {
+		return 0, err
+	}
+	if include == exclude {
+		return 0, nil
+	}
+
+	const (
+		fromInclude = 1 << iota
+		fromExclude
+	)
+
+	// Commits are processed in generation order, so all children of a commit
+	// have painted it before it is counted
+	flags := map[plumbing.Hash]uint8{include: fromInclude, exclude: fromExclude}
+	queued := map[plumbing.Hash]bool{include: true, exclude: true}
+	queue := &generationQueue{}
+	g.push(queue, include)
+	g.push(queue, exclude)
+
+	// Queued commits reachable only from include, the walk ends when none is left
+	onlyInclude := 1
+	count := 0
+
+	for queue.Len() > 0 && onlyInclude > 0 {
+		item := heap.Pop(queue).(queueItem)
+		delete(queued, item.hash)
+
+		f := flags[item.hash]
+		if f == fromInclude {
+			count++
+			onlyInclude--
+		}
+
+		n, _ := g.lookup(item.hash)
+		for _, p := range n.parents {
+			old := flags[p]
+			painted := old | f
+			if painted == old {
+				continue
+			}
+			flags[p] = painted
+
+			if queued[p] {
+				if old == fromInclude {
+					onlyInclude--
+				}
+			} else {
+				queued[p] = true
+				g.push(queue, p)
+			}
+			if painted == fromInclude {
+				onlyInclude++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// push adds a loaded commit to the queue
+func (g *commitGraph) push(queue *generationQueue, hash plumbing.Hash) {
+	n, _ := g.lookup(hash)
+	heap.Push(queue, queueItem{hash: hash, generation: n.generation})
+}
+
+// queueItem commit in a generation queue
+type queueItem struct {
+	hash       plumbing.Hash
+	generation uint64
+}
+
+// generationQueue priority queue returning the highest generation first
+type generationQueue []queueItem
+
+func (q generationQueue) Len() int { return len(q) }
+
+func (q generationQueue) Less(i, j int) bool {
+	if q[i].generation != q[j].generation {
+		return q[i].generation > q[j].generation
+	}
+	return bytes.Compare(q[i].hash[:], q[j].hash[:]) < 0
+}
+
+func (q generationQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *generationQueue) Push(x any) { *q = append(*q, x.(queueItem)) }
+
+func (q *generationQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
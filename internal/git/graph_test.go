@@ -0,0 +1,172 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"warmy/internal/config"
+	"warmy/internal/focus"
+	"warmy/internal/logger"
+	"warmy/internal/types"
+)
+
+// testHistory in-memory repository whose commits are created by the test
+type testHistory struct {
+	t    *testing.T
+	repo *git.Repository
+	tree plumbing.Hash
+	n    int64
+}
+
+// newTestHistory creates an empty in-memory repository
+func newTestHistory(t *testing.T) *testHistory {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testHistory{t: t, repo: repo, tree: storeObject(t, repo, (&object.Tree{}).Encode)}
+}
+
+// commit stores a commit by the author email with the given parents
+func (h *testHistory) commit(email string, parents ...plumbing.Hash) plumbing.Hash {
+	h.n++
+	author := object.Signature{Name: "Author", Email: email, When: time.Unix(1700000000+h.n, 0).UTC()}
+	commit := &object.Commit{
+		Author:       author,
+		Committer:    author,
+		Message:      fmt.Sprintf("commit %d\n", h.n),
+		TreeHash:     h.tree,
+		ParentHashes: parents,
+	}
+	return storeObject(h.t, h.repo, commit.Encode)
+}
+
+func TestCommitGraphContains(t *testing.T) {
+	// root - a - b ------ merge
+	//         \          /
+	//          side1 - side2
+	h := newTestHistory(t)
+	root := h.commit("x@example.com")
+	a := h.commit("x@example.com", root)
+	b := h.commit("x@example.com", a)
+	side1 := h.commit("x@example.com", a)
+	side2 := h.commit("x@example.com", side1)
+	merge := h.commit("x@example.com", b, side2)
+
+	tests := []struct {
+		name        string
+		tip, target plumbing.Hash
+		want        bool
+	}{
+		{name: "itself", tip: b, target: b, want: true},
+		{name: "parent", tip: b, target: a, want: true},
+		{name: "root", tip: merge, target: root, want: true},
+		{name: "second parent side", tip: merge, target: side1, want: true},
+		{name: "descendant", tip: a, target: b, want: false},
+		{name: "other branch", tip: b, target: side2, want: false},
+		{name: "other branch with higher generation", tip: side1, target: b, want: false},
+	}
+
+	g := newCommitGraph()
+	memos := make(map[plumbing.Hash]map[plumbing.Hash]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Memos are shared per target, as by refsContainingCommit
+			if memos[tt.target] == nil {
+				memos[tt.target] = make(map[plumbing.Hash]bool)
+			}
+			for _, memo := range []map[plumbing.Hash]bool{memos[tt.target], make(map[plumbing.Hash]bool)} {
+				got, err := g.contains(h.repo, tt.tip, tt.target, memo)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("contains(%s, %s) = %v, want %v", tt.tip, tt.target, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitGraphDeepHistory(t *testing.T) {
+	const depth = 20000
+
+	h := newTestHistory(t)
+	root := h.commit("x@example.com")
+	other := h.commit("x@example.com")
+	tip := root
+	for i := 0; i < depth; i++ {
+		tip = h.commit("x@example.com", tip)
+	}
+
+	g := newCommitGraph()
+	for _, tt := range []struct {
+		target plumbing.Hash
+		want   bool
+	}{{root, true}, {other, false}} {
+		got, err := g.contains(h.repo, tip, tt.target, make(map[plumbing.Hash]bool))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("contains(tip, %s) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestNewContributorTrust(t *testing.T) {
+	// alice - bob - alice2 - carol
+	//           \
+	//            dave - bob2 - empty - empty2
+	h := newTestHistory(t)
+	alice := h.commit("alice@example.com")
+	bob := h.commit("bob@example.com", alice)
+	alice2 := h.commit("Alice@Example.com", bob)
+	carol := h.commit("carol@example.com", alice2)
+	dave := h.commit("dave@example.com", bob)
+	bob2 := h.commit("bob@example.com", dave)
+	empty := h.commit("", bob2)
+	empty2 := h.commit(" ", empty)
+	carolSide := h.commit("carol@example.com", empty2)
+
+	log := logger.New("error", io.Discard)
+	engine, err := focus.New(config.FocusConfig{Enable: config.Bool(true), NewContributors: config.Bool(true)}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Analyzer{log: log, focus: engine, graphs: newGraphCache()}
+
+	tests := []struct {
+		name   string
+		commit plumbing.Hash
+		want   string
+	}{
+		{name: "first commit", commit: alice, want: types.TrustNewContributor},
+		{name: "first commit of an author", commit: bob, want: types.TrustNewContributor},
+		{name: "author email differs in case only", commit: alice2, want: ""},
+		{name: "later commit of an author", commit: bob2, want: ""},
+		{name: "empty email", commit: empty, want: ""},
+		{name: "blank email after empty email", commit: empty2, want: ""},
+		{name: "author of another branch", commit: carolSide, want: types.TrustNewContributor},
+		{name: "new author on a branch", commit: carol, want: types.TrustNewContributor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit, err := h.repo.CommitObject(tt.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.newContributorTrust(h.repo, commit); got != tt.want {
+				t.Errorf("newContributorTrust() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
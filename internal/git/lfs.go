@@ -0,0 +1,357 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"warmy/internal/logger"
+	"warmy/internal/types"
+
+	gitdiff "github.com/go-git/go-git/v5/utils/diff"
+)
+
+// lfsPointerMaxSize LFS pointer files are smaller than this, like git-lfs assumes
+const lfsPointerMaxSize = 1024
+
+// lfsVersions version lines of LFS pointer files, the current and the pre-release spec
+var lfsVersions = []string{
+	"https://git-lfs.github.com/spec/v1",
+	"https://hawser.github.com/spec/v1",
+}
+
+// lfsPointer parsed Git LFS pointer file
+type lfsPointer struct {
+	oid  string // sha256 object id, without the sha256: prefix
+	size int64  // Object size (bytes)
+}
+
+// parseLFSPointer parses the content of a Git LFS pointer file, ok is false if
+// the content is not a pointer. The version line comes first, oid and size are required.
+func parseLFSPointer(content []byte) (pointer lfsPointer, ok bool) {
+	if len(content) >= lfsPointerMaxSize {
+		return pointer, false
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	version, found := strings.CutPrefix(lines[0], "version ")
+	if !found || !types.Contains(lfsVersions, version) {
+		return pointer, false
+	}
+
+	hasSize := false
+	for _, line := range lines[1:] {
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			return pointer, false
+		}
+		switch key {
+		case "oid":
+			oid, found := strings.CutPrefix(value, "sha256:")
+			if !found || len(oid) != 64 || !isHex(oid) {
+				return pointer, false
+			}
+			pointer.oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return pointer, false
+			}
+			pointer.size = size
+			hasSize = true
+		}
+	}
+
+	return pointer, pointer.oid != "" && hasSize
+}
+
+// readLFSPointer reads a tree entry as LFS pointer, nil if the entry is not a pointer file
+func readLFSPointer(s storer.EncodedObjectStorer, entry object.TreeEntry) (*lfsPointer, error) {
+	if entry.Mode != filemode.Regular && entry.Mode != filemode.Executable {
+		return nil, nil
+	}
+
+	obj, err := s.EncodedObject(plumbing.BlobObject, entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Size() >= lfsPointerMaxSize {
+		return nil, nil
+	}
+
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if pointer, ok := parseLFSPointer(content); ok {
+		return &pointer, nil
+	}
+	return nil, nil
+}
+
+// readChangePointers reads both sides of a tree change as LFS pointers
+func readChangePointers(s storer.EncodedObjectStorer, change *object.Change) (from, to *lfsPointer, err error) {
+	if change.From.Name != "" {
+		if from, err = readLFSPointer(s, change.From.TreeEntry); err != nil {
+			return nil, nil, err
+		}
+	}
+	if change.To.Name != "" {
+		if to, err = readLFSPointer(s, change.To.TreeEntry); err != nil {
+			return nil, nil, err
+		}
+	}
+	return from, to, nil
+}
+
+// lfsStore local LFS object store of a repository, .git/lfs/objects
+type lfsStore struct {
+	dir string
+}
+
+// lfsObjectStore returns the LFS object store of a repository if LFS objects
+// are resolved, nil otherwise or if the repository is not stored on a filesystem
+func (a *Analyzer) lfsObjectStore(repo *git.Repository) *lfsStore {
+	if !a.cfg.LFS.Resolve.Value() {
+		return nil
+	}
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil
+	}
+	return &lfsStore{dir: filepath.Join(fsStorage.Filesystem().Root(), "lfs", "objects")}
+}
+
+// open opens the object of a pointer, ok is false if the object is missing
+// or not fully downloaded
+func (s *lfsStore) open(pointer *lfsPointer) (io.ReadCloser, bool, error) {
+	file, err := os.Open(filepath.Join(s.dir, pointer.oid[0:2], pointer.oid[2:4], pointer.oid))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.Size() != pointer.size {
+		file.Close()
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+// read reads the object of a pointer, ok is false if the object is not
+// available or larger than maxSize
+func (s *lfsStore) read(pointer *lfsPointer, maxSize int) ([]byte, bool, error) {
+	if pointer.size > int64(maxSize) {
+		return nil, false, nil
+	}
+
+	file, ok, err := s.open(pointer)
+	if !ok {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	return content, err == nil, err
+}
+
+// readLFSContent streams the object of a pointer like readBlobContent, ok is
+// false if there is no store or the object is not available
+func readLFSContent(store *lfsStore, pointer *lfsPointer, maxLines int, detectBinary bool, visit func(string)) (blobContent, bool, error) {
+	if store == nil {
+		return blobContent{}, false, nil
+	}
+
+	file, ok, err := store.open(pointer)
+	if !ok {
+		return blobContent{}, false, err
+	}
+	defer file.Close()
+
+	content, err := readContent(file, maxLines, detectBinary, visit)
+	return content, true, err
+}
+
+// lfsChangeInfo builds the LFS information of a change from the pointers before and after it
+func lfsChangeInfo(from, to *lfsPointer) *types.LFSChange {
+	info := &types.LFSChange{}
+	if from != nil {
+		info.OldOID = "sha256:" + from.oid
+		info.OldSize = from.size
+	}
+	if to != nil {
+		info.NewOID = "sha256:" + to.oid
+		info.NewSize = to.size
+	}
+	return info
+}
+
+// processLFSChange builds change information of a change of LFS pointer files.
+// With a local object store the objects are diffed like regular files, otherwise,
+// or if an object is missing, only the object ids and sizes are reported.
+func (a *Analyzer) processLFSChange(i int, treeChange *object.Change, from, to *lfsPointer, w *workerTrees, store *lfsStore, log logger.Logger) (fileChangeResult, error) {
+	info := lfsChangeInfo(from, to)
+
+	if store != nil {
+		var err error
+		var fromContent, toContent []byte
+		resolved := true
+		// A file converted from or to LFS has no object on its regular side
+		if treeChange.From.Name != "" {
+			fromContent, resolved, err = a.lfsSideContent(treeChange.From.TreeEntry, from, w, store)
+		}
+		if err == nil && resolved && treeChange.To.Name != "" {
+			toContent, resolved, err = a.lfsSideContent(treeChange.To.TreeEntry, to, w, store)
+		}
+		if err != nil {
+			return fileChangeResult{}, err
+		}
+
+		if resolved {
+			var result fileChangeResult
+			if isBinaryContent(fromContent) || isBinaryContent(toContent) {
+				result = a.processBinaryChange(i, treeChange, w, log)
+			} else {
+				result = a.processFilePatch(i, newContentFilePatch(treeChange, string(fromContent), string(toContent)), w.to, log)
+			}
+			info.Resolved = true
+			result.change.LFS = info
+			result.stats.LFSFiles++
+			return result, nil
+		}
+	}
+
+	result := fileChangeResult{ok: true}
+	fromPath, toPath := treeChange.From.Name, treeChange.To.Name
+
+	change := types.ChangeInfo{LFS: info}
+	filePath := setChangeAction(&change, &result.stats, fromPath, toPath, i, log)
+	change.Extension = types.GetFileExtension(filePath)
+	result.stats.LFSFiles++
+
+	fromName, toName := "/dev/null", "/dev/null"
+	if fromPath != "" {
+		fromName = "a/" + fromPath
+		change.OldBlobHash = treeChange.From.TreeEntry.Hash.String()
+		change.OldFileSize = blobSize(w.storer, treeChange.From.TreeEntry.Hash)
+	}
+	if toPath != "" {
+		toName = "b/" + toPath
+		change.BlobHash = treeChange.To.TreeEntry.Hash.String()
+		change.FileSize = blobSize(w.storer, treeChange.To.TreeEntry.Hash)
+	}
+
+	change.DiffContent = fmt.Sprintf("LFS objects %s and %s differ\n", fromName, toName)
+	result.diffSize = len(change.DiffContent)
+	result.change = change
+
+	log.WithFields(logger.Fields{
+		"file_index": i,
+		"file":       filePath,
+		"old_oid":    info.OldOID,
+		"new_oid":    info.NewOID,
+	}).Debug("Detected LFS file change")
+
+	return result, nil
+}
+
+// lfsSideContent returns the content of one side of an LFS change, the LFS
+// object for a pointer and the blob for a regular file
+func (a *Analyzer) lfsSideContent(entry object.TreeEntry, pointer *lfsPointer, w *workerTrees, store *lfsStore) ([]byte, bool, error) {
+	if pointer != nil {
+		return store.read(pointer, a.cfg.MaxDiffSize)
+	}
+
+	blob, err := object.GetBlob(w.storer, entry.Hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if blob.Size > int64(a.cfg.MaxDiffSize) {
+		return nil, false, nil
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	return content, err == nil, err
+}
+
+// contentFilePatch text patch of contents that are not stored as blobs, such as LFS objects
+type contentFilePatch struct {
+	from   diff.File
+	to     diff.File
+	chunks []diff.Chunk
+}
+
+// newContentFilePatch builds the patch of a tree change from the contents before and after it
+func newContentFilePatch(treeChange *object.Change, fromContent, toContent string) *contentFilePatch {
+	patch := &contentFilePatch{}
+	if treeChange.From.Name != "" {
+		patch.from = contentFile{path: treeChange.From.Name, entry: treeChange.From.TreeEntry}
+	}
+	if treeChange.To.Name != "" {
+		patch.to = contentFile{path: treeChange.To.Name, entry: treeChange.To.TreeEntry}
+	}
+
+	for _, d := range gitdiff.Do(fromContent, toContent) {
+		var op diff.Operation
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = diff.Add
+		case diffmatchpatch.DiffDelete:
+			op = diff.Delete
+		default:
+			op = diff.Equal
+		}
+		patch.chunks = append(patch.chunks, contentChunk{content: d.Text, op: op})
+	}
+	return patch
+}
+
+func (p *contentFilePatch) IsBinary() bool              { return false }
+func (p *contentFilePatch) Files() (from, to diff.File) { return p.from, p.to }
+func (p *contentFilePatch) Chunks() []diff.Chunk        { return p.chunks }
+
+// contentFile file of a contentFilePatch
+type contentFile struct {
+	path  string
+	entry object.TreeEntry
+}
+
+func (f contentFile) Hash() plumbing.Hash     { return f.entry.Hash }
+func (f contentFile) Mode() filemode.FileMode { return f.entry.Mode }
+func (f contentFile) Path() string            { return f.path }
+
+// contentChunk chunk of a contentFilePatch
+type contentChunk struct {
+	content string
+	op      diff.Operation
+}
+
+func (c contentChunk) Content() string      { return c.content }
+func (c contentChunk) Type() diff.Operation { return c.op }
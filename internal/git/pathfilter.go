@@ -0,0 +1,190 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// ignoreFile name of the file in the repository root listing paths excluded from analysis
+const ignoreFile = ".warmyignore"
+
+// pathRules compiled include and exclude rules of the paths configuration
+type pathRules struct {
+	include []*regexp.Regexp // Paths to analyze, all paths if empty
+	exclude []*regexp.Regexp // Paths to skip
+}
+
+// compilePathRules compiles the globs and regular expressions of the paths configuration
+func compilePathRules(cfg config.PathFilterConfig) (*pathRules, error) {
+	rules := &pathRules{}
+
+	for _, list := range []struct {
+		globs   []string
+		regexps []string
+		target  *[]*regexp.Regexp
+		name    string
+	}{
+		{cfg.Include, cfg.IncludeRegex, &rules.include, "include"},
+		{cfg.Exclude, cfg.ExcludeRegex, &rules.exclude, "exclude"},
+	} {
+		for _, glob := range list.globs {
+			re, err := regexp.Compile(globToRegexp(glob))
+			if err != nil {
+				return nil, fmt.Errorf("invalid paths.%s glob %q: %w", list.name, glob, err)
+			}
+			*list.target = append(*list.target, re)
+		}
+		regexps, err := types.CompileRegexps("paths."+list.name+"_regex", list.regexps)
+		if err != nil {
+			return nil, err
+		}
+		*list.target = append(*list.target, regexps...)
+	}
+
+	return rules, nil
+}
+
+// globToRegexp converts a glob to an anchored regular expression. * and ? do not
+// match /, ** matches any number of directories. A glob without / matches at any
+// depth, and a glob matching a directory matches everything below it.
+func globToRegexp(glob string) string {
+	glob = strings.TrimSuffix(strings.TrimPrefix(glob, "/"), "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(.*/)?")
+	}
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("(/.*)?$")
+	return b.String()
+}
+
+// pathFilter path filter of the analysis of one commit, combining the configured
+// rules with the .warmyignore file of the commit tree.
+// A filter is not safe for concurrent use.
+type pathFilter struct {
+	rules   *pathRules
+	ignore  gitignore.Matcher // Patterns of the .warmyignore file, nil if there is none
+	dropped map[string]bool   // Paths dropped by the filter
+}
+
+// newPathFilter creates the path filter of a commit tree
+func (a *Analyzer) newPathFilter(tree *object.Tree) *pathFilter {
+	filter := &pathFilter{rules: a.paths, dropped: make(map[string]bool)}
+	if a.cfg.Paths.IgnoreFile.Value() {
+		filter.ignore = readIgnoreFile(tree)
+	}
+	return filter
+}
+
+// readIgnoreFile parses the .warmyignore file of a tree, it uses the gitignore syntax
+func readIgnoreFile(tree *object.Tree) gitignore.Matcher {
+	file, err := tree.File(ignoreFile)
+	if err != nil {
+		return nil
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil
+	}
+
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return gitignore.NewMatcher(patterns)
+}
+
+// keepPath reports whether a path is analyzed, dropped paths are recorded
+func (f *pathFilter) keepPath(p string) bool {
+	if f.matches(p) {
+		return true
+	}
+	f.dropped[p] = true
+	return false
+}
+
+// keepChange reports whether a tree change is analyzed, a rename is analyzed
+// if either of its paths is
+func (f *pathFilter) keepChange(change *object.Change) bool {
+	if (change.From.Name != "" && f.matches(change.From.Name)) || (change.To.Name != "" && f.matches(change.To.Name)) {
+		return true
+	}
+	if change.To.Name != "" {
+		f.dropped[change.To.Name] = true
+	} else {
+		f.dropped[change.From.Name] = true
+	}
+	return false
+}
+
+// matches checks a path against the include, exclude and ignore file rules
+func (f *pathFilter) matches(p string) bool {
+	if len(f.rules.include) > 0 && !matchesAny(f.rules.include, p) {
+		return false
+	}
+	if matchesAny(f.rules.exclude, p) {
+		return false
+	}
+	if f.ignore != nil && f.ignore.Match(strings.Split(p, "/"), false) {
+		return false
+	}
+	return true
+}
+
+// filteredFiles returns the number of paths dropped by the filter
+func (f *pathFilter) filteredFiles() int {
+	return len(f.dropped)
+}
+
+// matchesAny checks if a path matches any of the patterns
+func matchesAny(patterns []*regexp.Regexp, p string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
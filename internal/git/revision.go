@@ -0,0 +1,111 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// minAbbrevLength shortest abbreviated hash git accepts
+const minAbbrevLength = 4
+
+// checkAmbiguousRevision returns an error listing the candidates when the
+// revision starts with an abbreviated hash matching more than one commit.
+// Names resolving as a ref, like a branch named "cafe", are not checked.
+func checkAmbiguousRevision(repo *git.Repository, rev string) error {
+	// Leading name of the revision, before any ~, ^ or @ suffix
+	name := rev
+	if idx := strings.IndexAny(name, "~^@:"); idx >= 0 {
+		name = name[:idx]
+	}
+	prefix := strings.ToLower(name)
+	if len(prefix) < minAbbrevLength || len(prefix) >= len(plumbing.ZeroHash)*2 || !isHex(prefix) {
+		return nil
+	}
+	if isRef(repo, name) {
+		return nil
+	}
+
+	var candidates []string
+	for _, hash := range hashesWithPrefix(repo.Storer, prefix) {
+		if commit, err := repo.CommitObject(hash); err == nil {
+			candidates = append(candidates, fmt.Sprintf("%s commit %s",
+				hash.String(), strings.Split(commit.Message, "\n")[0]))
+			continue
+		}
+		if tag, err := repo.TagObject(hash); err == nil {
+			candidates = append(candidates, fmt.Sprintf("%s tag %s", hash.String(), tag.Name))
+		}
+	}
+
+	if len(candidates) > 1 {
+		return fmt.Errorf("ambiguous short hash %s, candidates:\n  %s", prefix, strings.Join(candidates, "\n  "))
+	}
+	return nil
+}
+
+// isRef reports whether a name resolves as a ref, in the order git looks them up
+func isRef(repo *git.Repository, name string) bool {
+	for _, ref := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name,
+		"refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		if _, err := repo.Reference(plumbing.ReferenceName(ref), true); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hashesWithPrefix returns hashes of all objects starting with the hex prefix
+func hashesWithPrefix(s storer.EncodedObjectStorer, prefix string) []plumbing.Hash {
+	// Decode whole bytes only, a trailing half byte is compared as string
+	prefixBytes, err := hex.DecodeString(prefix[:len(prefix)&^1])
+	if err != nil {
+		return nil
+	}
+
+	var candidates []plumbing.Hash
+	type prefixStorer interface {
+		HashesWithPrefix(prefix []byte) ([]plumbing.Hash, error)
+	}
+	if ps, ok := s.(prefixStorer); ok {
+		candidates, err = ps.HashesWithPrefix(prefixBytes)
+		if err != nil {
+			return nil
+		}
+	} else {
+		iter, err := s.IterEncodedObjects(plumbing.AnyObject)
+		if err != nil {
+			return nil
+		}
+		_ = iter.ForEach(func(obj plumbing.EncodedObject) error {
+			hash := obj.Hash()
+			if bytes.HasPrefix(hash[:], prefixBytes) {
+				candidates = append(candidates, hash)
+			}
+			return nil
+		})
+	}
+
+	hashes := make([]plumbing.Hash, 0, len(candidates))
+	for _, hash := range candidates {
+		if strings.HasPrefix(hash.String(), prefix) {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// isHex reports whether s consists of hexadecimal digits only
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,332 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// Armor headers of the signature formats git supports
+const (
+	pgpSignatureHeader  = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureHeader  = "-----BEGIN SSH SIGNATURE-----"
+	x509SignatureHeader = "-----BEGIN SIGNED MESSAGE-----"
+)
+
+// sshSignatureMagic preamble of SSH signatures, see PROTOCOL.sshsig of OpenSSH
+const sshSignatureMagic = "SSHSIG"
+
+// allowedSigner entry of an SSH allowed signers file
+type allowedSigner struct {
+	principals string // Comma separated principals of the key
+	key        ssh.PublicKey
+}
+
+// signatureVerifier verifies commit and tag signatures against the configured keys.
+// A verifier is immutable after creation and safe for concurrent use.
+type signatureVerifier struct {
+	keyring openpgp.EntityList // OpenPGP public keys, nil if no keyring is configured
+	signers []allowedSigner    // SSH keys allowed to sign, nil if no allowed signers file is configured
+}
+
+// newSignatureVerifier loads the keyring and allowed signers file of the configuration
+func newSignatureVerifier(cfg config.SignatureConfig) (*signatureVerifier, error) {
+	v := &signatureVerifier{}
+
+	if cfg.Keyring != "" {
+		keyring, err := readKeyring(cfg.Keyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signatures.keyring: %w", err)
+		}
+		v.keyring = keyring
+	}
+
+	if cfg.AllowedSigners != "" {
+		signers, err := readAllowedSigners(cfg.AllowedSigners)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signatures.allowed_signers: %w", err)
+		}
+		v.signers = signers
+	}
+
+	return v, nil
+}
+
+// readKeyring reads an armored or binary OpenPGP keyring
+func readKeyring(path string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data)); err == nil {
+		return keyring, nil
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+// readAllowedSigners reads an SSH allowed signers file, the format of git's
+// gpg.ssh.allowedSignersFile. Entries restricted to other namespaces and
+// certificate authorities are skipped.
+func readAllowedSigners(path string) ([]allowedSigner, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	signers := make([]allowedSigner, 0)
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principals, rest, _ := strings.Cut(line, " ")
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if !signerOptionsAllowGit(options) {
+			continue
+		}
+		signers = append(signers, allowedSigner{principals: strings.Trim(principals, `"`), key: key})
+	}
+
+	return signers, scanner.Err()
+}
+
+// signerOptionsAllowGit checks the options of an allowed signer for the git namespace
+func signerOptionsAllowGit(options []string) bool {
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			return false
+		case "namespaces":
+			if !types.Contains(strings.Split(strings.Trim(value, `"`), ","), "git") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// verifyObject verifies the signature of a commit or tag, nil if it is unsigned.
+// encode writes the object without its signature.
+func (v *signatureVerifier) verifyObject(signature string, encode func(plumbing.EncodedObject) error) *types.SignatureInfo {
+	if signature == "" {
+		return nil
+	}
+
+	obj := &plumbing.MemoryObject{}
+	if err := encode(obj); err != nil {
+		return &types.SignatureInfo{Status: types.SignatureBad, Error: err.Error()}
+	}
+	reader, err := obj.Reader()
+	if err != nil {
+		return &types.SignatureInfo{Status: types.SignatureBad, Error: err.Error()}
+	}
+	defer reader.Close()
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return &types.SignatureInfo{Status: types.SignatureBad, Error: err.Error()}
+	}
+
+	switch {
+	case strings.HasPrefix(signature, pgpSignatureHeader):
+		return v.verifyPGP(signature, payload)
+	case strings.HasPrefix(signature, sshSignatureHeader):
+		return v.verifySSH(signature, payload)
+	case strings.HasPrefix(signature, x509SignatureHeader):
+		return &types.SignatureInfo{Format: types.SignatureX509, Status: types.SignatureUnverified}
+	}
+	return &types.SignatureInfo{Status: types.SignatureBad, Error: "unknown signature format"}
+}
+
+// verifyPGP verifies an armored OpenPGP signature against the keyring
+func (v *signatureVerifier) verifyPGP(signature string, payload []byte) *types.SignatureInfo {
+	info := &types.SignatureInfo{Format: types.SignatureOpenPGP, KeyID: pgpIssuer(signature)}
+	if v.keyring == nil {
+		info.Status = types.SignatureUnverified
+		return info
+	}
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(v.keyring, bytes.NewReader(payload), strings.NewReader(signature), nil)
+	switch {
+	case errors.Is(err, pgperrors.ErrUnknownIssuer):
+		info.Status = types.SignatureUnknownKey
+	case err != nil:
+		info.Status = types.SignatureBad
+		info.Error = err.Error()
+	default:
+		info.Status = types.SignatureGood
+		if identity := signer.PrimaryIdentity(); identity != nil {
+			info.Signer = identity.Name
+		}
+	}
+	return info
+}
+
+// pgpIssuer returns the issuer key id of an armored OpenPGP signature, empty if unknown
+func pgpIssuer(signature string) string {
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return ""
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return ""
+	}
+	sig, ok := p.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return ""
+	}
+	return fmt.Sprintf("%016X", *sig.IssuerKeyId)
+}
+
+// sshSignature SSH signature blob after its magic preamble
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// verifySSH verifies an armored SSH signature. Without allowed signers file a valid
+// signature stays unverified, as nothing tells whether its key is trusted.
+func (v *signatureVerifier) verifySSH(signature string, payload []byte) *types.SignatureInfo {
+	info := &types.SignatureInfo{Format: types.SignatureSSH}
+
+	sig, key, err := parseSSHSignature(signature)
+	if err != nil {
+		info.Status = types.SignatureBad
+		info.Error = err.Error()
+		return info
+	}
+	info.KeyID = ssh.FingerprintSHA256(key)
+
+	if err := checkSSHSignature(sig, key, payload); err != nil {
+		info.Status = types.SignatureBad
+		info.Error = err.Error()
+		return info
+	}
+
+	if v.signers == nil {
+		info.Status = types.SignatureUnverified
+		return info
+	}
+	info.Status = types.SignatureUnknownKey
+	for _, signer := range v.signers {
+		if bytes.Equal(signer.key.Marshal(), key.Marshal()) {
+			info.Status = types.SignatureGood
+			info.Signer = signer.principals
+			break
+		}
+	}
+	return info
+}
+
+// parseSSHSignature decodes an armored SSH signature and its public key
+func parseSSHSignature(signature string) (*sshSignature, ssh.PublicKey, error) {
+	var encoded strings.Builder
+	for _, line := range strings.Split(signature, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "-----") {
+			encoded.WriteString(line)
+		}
+	}
+	blob, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SSH signature encoding: %w", err)
+	}
+
+	rest, found := bytes.CutPrefix(blob, []byte(sshSignatureMagic))
+	if !found {
+		return nil, nil, errors.New("invalid SSH signature preamble")
+	}
+	sig := &sshSignature{}
+	if err := ssh.Unmarshal(rest, sig); err != nil {
+		return nil, nil, fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return nil, nil, fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+
+	key, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SSH signature key: %w", err)
+	}
+	return sig, key, nil
+}
+
+// checkSSHSignature checks an SSH signature of the git namespace over the payload
+func checkSSHSignature(sig *sshSignature, key ssh.PublicKey, payload []byte) error {
+	if sig.Namespace != "git" {
+		return fmt.Errorf("SSH signature namespace %q is not git", sig.Namespace)
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	return key.Verify(signed, signature)
+}
+
+// tagSignatures verifies the signatures of the annotated tags pointing at a commit.
+// Tags of tags are peeled to their commit, the signature of the tag object the
+// reference points to is verified.
+func (a *Analyzer) tagSignatures(repo *git.Repository, hash plumbing.Hash, tags []refTip) []types.TagSignatureInfo {
+	var signatures []types.TagSignatureInfo
+	for _, tip := range tags {
+		if !tip.annotated || tip.commit != hash {
+			continue
+		}
+		tag, err := repo.TagObject(tip.object)
+		if err != nil {
+			continue
+		}
+		if info := a.signatures.verifyObject(tag.PGPSignature, tag.EncodeWithoutSignature); info != nil {
+			signatures = append(signatures, types.TagSignatureInfo{Tag: tip.name, SignatureInfo: *info})
+		}
+	}
+	return signatures
+}
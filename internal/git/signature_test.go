@@ -0,0 +1,240 @@
+package git
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/crypto/ssh"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// Fixtures in testdata/ssh were created with ssh-keygen -Y sign: good.sig by the key
+// of allowed_signers, unknown-key.sig by another key and wrong-namespace.sig by the
+// allowed key in the "file" namespace, all over payload.
+
+// readFixture reads a file of testdata/ssh
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "ssh", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// corruptSSHSignature returns an armored SSH signature with a flipped signature byte
+func corruptSSHSignature(t *testing.T, signature string) string {
+	t.Helper()
+	sig, _, err := parseSSHSignature(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig.Signature[len(sig.Signature)-1] ^= 0xff
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sig)...)
+	return sshSignatureHeader + "\n" + base64.StdEncoding.EncodeToString(blob) + "\n-----END SSH SIGNATURE-----\n"
+}
+
+func TestVerifySSH(t *testing.T) {
+	payload := readFixture(t, "payload")
+	good := readFixture(t, "good.sig")
+
+	tests := []struct {
+		name           string
+		allowedSigners string // Allowed signers file, empty for none
+		signature      string
+		payload        string
+		wantStatus     string
+		wantSigner     string
+		wantError      string
+	}{
+		{
+			name:           "good",
+			allowedSigners: "allowed_signers",
+			signature:      good,
+			payload:        payload,
+			wantStatus:     types.SignatureGood,
+			wantSigner:     "alice@example.com",
+		},
+		{
+			name:       "good without allowed signers",
+			signature:  good,
+			payload:    payload,
+			wantStatus: types.SignatureUnverified,
+		},
+		{
+			name:           "tampered payload",
+			allowedSigners: "allowed_signers",
+			signature:      good,
+			payload:        strings.Replace(payload, "Signed commit", "Signed commit!", 1),
+			wantStatus:     types.SignatureBad,
+			wantError:      "did not verify",
+		},
+		{
+			name:           "corrupted signature",
+			allowedSigners: "allowed_signers",
+			signature:      corruptSSHSignature(t, good),
+			payload:        payload,
+			wantStatus:     types.SignatureBad,
+			wantError:      "did not verify",
+		},
+		{
+			name:           "invalid encoding",
+			allowedSigners: "allowed_signers",
+			signature:      sshSignatureHeader + "\n!!!\n-----END SSH SIGNATURE-----\n",
+			payload:        payload,
+			wantStatus:     types.SignatureBad,
+			wantError:      "invalid SSH signature encoding",
+		},
+		{
+			name:           "unknown key",
+			allowedSigners: "allowed_signers",
+			signature:      readFixture(t, "unknown-key.sig"),
+			payload:        payload,
+			wantStatus:     types.SignatureUnknownKey,
+		},
+		{
+			name:           "wrong namespace",
+			allowedSigners: "allowed_signers",
+			signature:      readFixture(t, "wrong-namespace.sig"),
+			payload:        payload,
+			wantStatus:     types.SignatureBad,
+			wantError:      `namespace "file" is not git`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.SignatureConfig{}
+			if tt.allowedSigners != "" {
+				cfg.AllowedSigners = filepath.Join("testdata", "ssh", tt.allowedSigners)
+			}
+			v, err := newSignatureVerifier(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info := v.verifySSH(tt.signature, []byte(tt.payload))
+			if info.Format != types.SignatureSSH {
+				t.Errorf("format = %q, want %q", info.Format, types.SignatureSSH)
+			}
+			if info.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q (error %q)", info.Status, tt.wantStatus, info.Error)
+			}
+			if info.Signer != tt.wantSigner {
+				t.Errorf("signer = %q, want %q", info.Signer, tt.wantSigner)
+			}
+			if !strings.Contains(info.Error, tt.wantError) {
+				t.Errorf("error = %q, want %q", info.Error, tt.wantError)
+			}
+			if tt.wantStatus != types.SignatureBad || tt.wantError == "did not verify" {
+				if !strings.HasPrefix(info.KeyID, "SHA256:") {
+					t.Errorf("key id = %q, want SSH fingerprint", info.KeyID)
+				}
+			}
+		})
+	}
+}
+
+func TestSignerOptionsAllowGit(t *testing.T) {
+	tests := []struct {
+		options []string
+		want    bool
+	}{
+		{options: nil, want: true},
+		{options: []string{`namespaces="git"`}, want: true},
+		{options: []string{`namespaces="file,git"`}, want: true},
+		{options: []string{`namespaces="file"`}, want: false},
+		{options: []string{"cert-authority"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := signerOptionsAllowGit(tt.options); got != tt.want {
+			t.Errorf("signerOptionsAllowGit(%q) = %v, want %v", tt.options, got, tt.want)
+		}
+	}
+}
+
+// storeObject encodes an object into the repository storage
+func storeObject(t *testing.T, repo *git.Repository, encode func(plumbing.EncodedObject) error) plumbing.Hash {
+	t.Helper()
+	obj := repo.Storer.NewEncodedObject()
+	if err := encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestTagSignatures(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Unix(1700000000, 0).UTC()}
+	tree := storeObject(t, repo, (&object.Tree{}).Encode)
+	newCommit := func(message string) plumbing.Hash {
+		commit := &object.Commit{Author: signature, Committer: signature, Message: message, TreeHash: tree}
+		return storeObject(t, repo, commit.Encode)
+	}
+	// newTag stores a tag object, signed with an X.509 signature that is reported
+	// unverified without being checked
+	newTag := func(name string, target plumbing.Hash, targetType plumbing.ObjectType, signed bool) plumbing.Hash {
+		tag := &object.Tag{Name: name, Tagger: signature, Message: name + "\n", Target: target, TargetType: targetType}
+		if signed {
+			tag.PGPSignature = x509SignatureHeader + "\n-----END SIGNED MESSAGE-----\n"
+		}
+		return storeObject(t, repo, tag.Encode)
+	}
+	setRef := func(name string, hash plumbing.Hash) {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit := newCommit("tagged")
+	other := newCommit("other")
+
+	setRef("direct", newTag("direct", commit, plumbing.CommitObject, true))
+	setRef("unsigned", newTag("unsigned", commit, plumbing.CommitObject, false))
+	setRef("lightweight", commit)
+	setRef("other", newTag("other", other, plumbing.CommitObject, true))
+	inner := newTag("inner", commit, plumbing.CommitObject, false)
+	setRef("nested", newTag("nested", inner, plumbing.TagObject, true))
+	middle := newTag("middle", commit, plumbing.CommitObject, true)
+	setRef("nested-twice", newTag("nested-twice", newTag("outer", middle, plumbing.TagObject, false), plumbing.TagObject, true))
+
+	tips, err := listRefTips(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Analyzer{signatures: &signatureVerifier{}}
+
+	var got []string
+	for _, info := range a.tagSignatures(repo, commit, tips.tags) {
+		if info.Status != types.SignatureUnverified || info.Format != types.SignatureX509 {
+			t.Errorf("tag %s signature = %+v, want unverified x509", info.Tag, info.SignatureInfo)
+		}
+		got = append(got, info.Tag)
+	}
+
+	sort.Strings(got)
+	want := []string{"direct", "nested", "nested-twice"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("tag signatures = %v, want %v", got, want)
+	}
+}
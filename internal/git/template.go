@@ -0,0 +1,50 @@
+package git
+
+import (
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"warmy/internal/logger"
+	"warmy/internal/nuclei"
+	"warmy/internal/types"
+)
+
+// analyzesTemplate reports whether a changed file is parsed as a nuclei template
+func (a *Analyzer) analyzesTemplate(path string) bool {
+	return a.cfg.Templates.Enable.Value() && nuclei.IsTemplatePath(path)
+}
+
+// compareTemplates parses the content of a file before and after a change as nuclei
+// templates and reports its template level changes, nil if neither side is a template
+// or a side cannot be parsed. Empty content means the file does not exist on that side.
+func compareTemplates(path string, before, after []byte, log logger.Logger) *types.TemplateChange {
+	from, err := nuclei.Parse(before)
+	if err == nil {
+		var to *nuclei.Template
+		if to, err = nuclei.Parse(after); err == nil {
+			return nuclei.Compare(from, to)
+		}
+	}
+
+	log.WithFields(logger.Fields{
+		"file":  path,
+		"error": err.Error(),
+	}).Debug("Failed to parse template")
+	return nil
+}
+
+// rootTemplate parses a file added by the root commit as a nuclei template
+func rootTemplate(path string, blob *object.Blob, log logger.Logger) *types.TemplateChange {
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil
+	}
+	return compareTemplates(path, nil, content, log)
+}
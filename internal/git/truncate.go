@@ -0,0 +1,161 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// omittedMarkerSize space reserved in a truncated diff for the omitted lines marker
+const omittedMarkerSize = 64
+
+// diffParts diff of a file split into its header and hunks, kept so the diff can be
+// truncated to different budgets
+type diffParts struct {
+	header string     // Diff header lines
+	hunks  [][]string // Changed lines of each hunk, with their +/- prefix and newline
+}
+
+// String joins header and hunks to the complete diff
+func (d *diffParts) String() string {
+	var b strings.Builder
+	b.WriteString(d.header)
+	for _, hunk := range d.hunks {
+		for _, line := range hunk {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// size returns the size of the complete diff
+func (d *diffParts) size() int {
+	size := len(d.header)
+	for _, hunk := range d.hunks {
+		for _, line := range hunk {
+			size += len(line)
+		}
+	}
+	return size
+}
+
+// truncate returns the diff within budget bytes and the number of omitted lines.
+// Up to keep hunks are kept from the start and from the end of the diff, the start
+// gets half of the budget and the end the rest. A hunk larger than the remaining
+// budget is cut at a line boundary.
+func (d *diffParts) truncate(budget, keep int) (string, int) {
+	if d.size() <= budget {
+		return d.String(), 0
+	}
+
+	type hunkLine struct {
+		text string
+		hunk int
+	}
+	var lines []hunkLine
+	for i, hunk := range d.hunks {
+		for _, line := range hunk {
+			lines = append(lines, hunkLine{text: line, hunk: i})
+		}
+	}
+
+	// Without room for a line and the marker all hunks are dropped, the header is
+	// kept if it fits and the marker only if it fits after it
+	if budget < len(d.header)+omittedMarkerSize {
+		var b strings.Builder
+		if len(d.header) <= budget {
+			b.WriteString(d.header)
+		}
+		if marker := omittedMarker(len(lines)); b.Len()+len(marker) <= budget {
+			b.WriteString(marker)
+		}
+		return b.String(), len(lines)
+	}
+
+	available := budget - len(d.header) - omittedMarkerSize
+
+	// Leading lines of the first hunks
+	front, used := 0, 0
+	for front < len(lines) && lines[front].hunk < keep && used+len(lines[front].text) <= available/2 {
+		used += len(lines[front].text)
+		front++
+	}
+
+	// Trailing lines of the last hunks, with the budget left by the start
+	back := len(lines)
+	for back > front && lines[back-1].hunk >= len(d.hunks)-keep && used+len(lines[back-1].text) <= available {
+		used += len(lines[back-1].text)
+		back--
+	}
+
+	omitted := back - front
+	var b strings.Builder
+	b.WriteString(d.header)
+	for _, line := range lines[:front] {
+		b.WriteString(line.text)
+	}
+	if omitted > 0 {
+		b.WriteString(omittedMarker(omitted))
+	}
+	for _, line := range lines[back:] {
+		b.WriteString(line.text)
+	}
+	return b.String(), omitted
+}
+
+// omittedMarker returns the line replacing omitted lines of a truncated diff
+func omittedMarker(omitted int) string {
+	return fmt.Sprintf("... %d lines omitted ...\n", omitted)
+}
+
+// setDiff sets the diff of a file change, truncated to max_diff_size
+func (a *Analyzer) setDiff(result *fileChangeResult, parts *diffParts) {
+	result.parts = parts
+	result.diffSize = parts.size()
+	result.diffTooLarge = result.diffSize > a.cfg.MaxDiffSize
+	a.truncateDiff(result, a.cfg.MaxDiffSize)
+}
+
+// truncateDiff sets the diff content of a file change truncated to budget bytes.
+// The added and deleted line lists are parsed from the truncated diff, so they
+// hold the same lines as the diff content.
+func (a *Analyzer) truncateDiff(result *fileChangeResult, budget int) {
+	change := &result.change
+	change.DiffContent, change.OmittedLines = result.parts.truncate(budget, a.cfg.TruncateHunks)
+	change.Truncated = change.OmittedLines > 0
+
+	if a.cfg.ParseDiff.Value() {
+		change.AdditionsList, change.DeletionsList = a.parseDiffContent(change.DiffContent)
+	}
+}
+
+// applyReportBudget truncates the diffs of file changes so that together they fit
+// into max_total_diff_size. The budget is shared evenly, diffs smaller than their
+// share are kept and leave the rest of their share to the larger diffs.
+func (a *Analyzer) applyReportBudget(results []fileChangeResult) {
+	budget := a.cfg.MaxTotalDiffSize
+	if budget <= 0 {
+		return
+	}
+
+	var order []int
+	for i := range results {
+		if results[i].ok {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(results[order[i]].change.DiffContent) < len(results[order[j]].change.DiffContent)
+	})
+
+	remaining := budget
+	for k, i := range order {
+		result := &results[i]
+		share := remaining / (len(order) - k)
+		// Binary, LFS and submodule diffs are short summaries that are always kept
+		if len(result.change.DiffContent) > share && result.parts != nil {
+			a.truncateDiff(result, share)
+		}
+		remaining = max(remaining-len(result.change.DiffContent), 0)
+	}
+}
@@ -0,0 +1,228 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"warmy/internal/config"
+)
+
+const testDiffHeader = "diff --git a/f b/f\n"
+
+// testDiff returns a diff of hunks hunks with lines lines each, every line is 12 bytes
+func testDiff(hunks, lines int) *diffParts {
+	d := &diffParts{header: testDiffHeader}
+	for h := 0; h < hunks; h++ {
+		var hunk []string
+		for l := 0; l < lines; l++ {
+			hunk = append(hunk, fmt.Sprintf("+h%d line %02d\n", h, l))
+		}
+		d.hunks = append(d.hunks, hunk)
+	}
+	return d
+}
+
+// hunkText returns the lines of the given hunks of d
+func hunkText(d *diffParts, hunks ...int) string {
+	var b strings.Builder
+	for _, h := range hunks {
+		b.WriteString(strings.Join(d.hunks[h], ""))
+	}
+	return b.String()
+}
+
+func TestDiffPartsTruncate(t *testing.T) {
+	tests := []struct {
+		name        string
+		diff        *diffParts
+		budget      int
+		keep        int
+		want        func(d *diffParts) string
+		wantOmitted int
+	}{
+		{
+			name:   "fits",
+			diff:   testDiff(5, 4),
+			budget: 259,
+			keep:   1,
+			want:   func(d *diffParts) string { return d.String() },
+		},
+		{
+			name:   "first and last hunk",
+			diff:   testDiff(5, 4),
+			budget: 179,
+			keep:   1,
+			want: func(d *diffParts) string {
+				return testDiffHeader + hunkText(d, 0) + omittedMarker(12) + hunkText(d, 4)
+			},
+			wantOmitted: 12,
+		},
+		{
+			name:   "keep limits retained hunks below the budget",
+			diff:   testDiff(5, 4),
+			budget: 250,
+			keep:   1,
+			want: func(d *diffParts) string {
+				return testDiffHeader + hunkText(d, 0) + omittedMarker(12) + hunkText(d, 4)
+			},
+			wantOmitted: 12,
+		},
+		{
+			name:   "first and last two hunks",
+			diff:   testDiff(5, 4),
+			budget: 250,
+			keep:   2,
+			want: func(d *diffParts) string {
+				return testDiffHeader + hunkText(d, 0) + strings.Join(d.hunks[1][:2], "") +
+					omittedMarker(7) + strings.Join(d.hunks[3][1:], "") + hunkText(d, 4)
+			},
+			wantOmitted: 7,
+		},
+		{
+			name:   "single hunk cut at line boundaries",
+			diff:   testDiff(1, 20),
+			budget: 143,
+			keep:   1,
+			want: func(d *diffParts) string {
+				return testDiffHeader + strings.Join(d.hunks[0][:2], "") +
+					omittedMarker(15) + strings.Join(d.hunks[0][17:], "")
+			},
+			wantOmitted: 15,
+		},
+		{
+			name:        "budget for header and marker only",
+			diff:        testDiff(2, 10),
+			budget:      50,
+			keep:        1,
+			want:        func(d *diffParts) string { return testDiffHeader + omittedMarker(20) },
+			wantOmitted: 20,
+		},
+		{
+			name:        "budget for header only",
+			diff:        testDiff(2, 10),
+			budget:      30,
+			keep:        1,
+			want:        func(d *diffParts) string { return testDiffHeader },
+			wantOmitted: 20,
+		},
+		{
+			name:        "budget smaller than one line",
+			diff:        testDiff(2, 10),
+			budget:      10,
+			keep:        1,
+			want:        func(d *diffParts) string { return "" },
+			wantOmitted: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, omitted := tt.diff.truncate(tt.budget, tt.keep)
+			if want := tt.want(tt.diff); got != want {
+				t.Errorf("truncate() =\n%s\nwant\n%s", got, want)
+			}
+			if omitted != tt.wantOmitted {
+				t.Errorf("omitted = %d, want %d", omitted, tt.wantOmitted)
+			}
+			if len(got) > tt.budget {
+				t.Errorf("len = %d, exceeds budget %d", len(got), tt.budget)
+			}
+		})
+	}
+}
+
+func TestApplyReportBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget int
+		diffs  []*diffParts // nil for a binary change
+		want   []int        // Expected share of each diff, -1 if kept
+	}{
+		{
+			name:   "no budget",
+			budget: 0,
+			diffs:  []*diffParts{testDiff(1, 100), testDiff(1, 100)},
+			want:   []int{-1, -1},
+		},
+		{
+			name:   "fits",
+			budget: 5000,
+			diffs:  []*diffParts{testDiff(1, 100), testDiff(1, 100)},
+			want:   []int{-1, -1},
+		},
+		{
+			name:   "even split",
+			budget: 600,
+			diffs:  []*diffParts{testDiff(1, 100), testDiff(2, 50), testDiff(1, 100)},
+			want:   []int{600 / 3, 600 / 3, 600 / 3},
+		},
+		{
+			name:   "small diff leaves its share to larger diffs",
+			budget: 600,
+			diffs:  []*diffParts{testDiff(1, 100), testDiff(1, 2), testDiff(1, 100)},
+			want:   []int{(600 - 43) / 2, -1, (600 - 43) / 2},
+		},
+		{
+			name:   "binary changes are kept",
+			budget: 300,
+			diffs:  []*diffParts{nil, testDiff(1, 100)},
+			want:   []int{-1, 300 - len("Binary file changed\n")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Analyzer{cfg: &config.Config{MaxTotalDiffSize: tt.budget, TruncateHunks: 3}}
+
+			results := make([]fileChangeResult, len(tt.diffs)+1)
+			for i, parts := range tt.diffs {
+				results[i].ok = true
+				results[i].parts = parts
+				if parts == nil {
+					results[i].change.DiffContent = "Binary file changed\n"
+				} else {
+					results[i].change.DiffContent = parts.String()
+				}
+			}
+			// Results that are not set are ignored
+			results[len(tt.diffs)].change.DiffContent = strings.Repeat("x", 10000)
+
+			a.applyReportBudget(results)
+
+			total := 0
+			for i, parts := range tt.diffs {
+				change := results[i].change
+				total += len(change.DiffContent)
+
+				if tt.want[i] < 0 {
+					if change.Truncated {
+						t.Errorf("diff %d truncated, want kept", i)
+					}
+					continue
+				}
+				// Diffs are cut at line boundaries and reserve room for the marker, the unused
+				// rest of a share goes to the following diffs
+				if size := len(change.DiffContent); size < tt.want[i]-omittedMarkerSize-12 || size > tt.want[i]+omittedMarkerSize+12 {
+					t.Errorf("diff %d size = %d, want about %d", i, size, tt.want[i])
+				}
+				if !change.Truncated || change.OmittedLines == 0 {
+					t.Errorf("diff %d not marked truncated: truncated=%v omitted=%d", i, change.Truncated, change.OmittedLines)
+				}
+
+				// Kept and omitted lines add up to the lines of the complete diff
+				lines := 0
+				for _, hunk := range parts.hunks {
+					lines += len(hunk)
+				}
+				kept := strings.Count(change.DiffContent, "\n") - strings.Count(testDiffHeader, "\n") - 1
+				if kept+change.OmittedLines != lines {
+					t.Errorf("diff %d keeps %d and omits %d of %d lines", i, kept, change.OmittedLines, lines)
+				}
+			}
+			if tt.budget > 0 && total > tt.budget {
+				t.Errorf("total size = %d, exceeds max_total_diff_size %d", total, tt.budget)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// workerTrees object storage and trees owned by a single worker
+type workerTrees struct {
+	storer    storer.EncodedObjectStorer
+	from      *object.Tree       // Tree before the change, nil for initial commits
+	to        *object.Tree       // Tree after the change
+	fromAttrs *attributeResolver // gitattributes of the tree before the change
+	toAttrs   *attributeResolver // gitattributes of the tree after the change
+}
+
+// newWorkerTrees loads the compared trees through a storage owned by the calling worker.
+// go-git's filesystem storage is not safe for concurrent use, so every worker
+// opens its own storage on the same .git directory.
+func newWorkerTrees(repo *git.Repository, fromHash, toHash plumbing.Hash) (*workerTrees, error) {
+	var s storer.EncodedObjectStorer = repo.Storer
+	if fsStorage, ok := repo.Storer.(*filesystem.Storage); ok {
+		s = filesystem.NewStorage(fsStorage.Filesystem(), cache.NewObjectLRUDefault())
+	}
+
+	w := &workerTrees{storer: s}
+
+	var err error
+	if !fromHash.IsZero() {
+		w.from, err = object.GetTree(s, fromHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.to, err = object.GetTree(s, toHash)
+	if err != nil {
+		return nil, err
+	}
+	w.fromAttrs = newAttributeResolver(w.from)
+	w.toAttrs = newAttributeResolver(w.to)
+
+	return w, nil
+}
+
+// openPrivate returns a repository instance used by the calling goroutine only.
+// go-git's filesystem storage is not safe for concurrent use, so a repository on
+// filesystem storage is reopened on its own storage; other storages are only read
+// and are shared.
+func openPrivate(repo *git.Repository) (*git.Repository, error) {
+	fsStorage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo, nil
+	}
+	return git.Open(filesystem.NewStorage(fsStorage.Filesystem(), cache.NewObjectLRUDefault()), nil)
+}
+
+// workerCount returns the number of workers to use for the given number of jobs
+func workerCount(configured, jobs int) int {
+	workers := configured
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > jobs {
+		workers = jobs
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// runWorkers processes jobs [0, count) on a bounded pool of workers.
+// Each worker creates its own state with newWorker, process stores its result
+// by job index so callers can keep a deterministic order. The first error, or
+// cancellation of ctx, stops the remaining jobs and is returned.
+func runWorkers[W any](ctx context.Context, count, workers int, newWorker func() (W, error), process func(w W, index int) error) error {
+	if count == 0 {
+		return nil
+	}
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w, err := newWorker()
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			for index := range jobs {
+				if err := process(w, index); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+	// Feed jobs until finished or failed
+feed:
+	for index := 0; index < count; index++ {
+		select {
+		case jobs <- index:
+		case <-done:
+			break feed
+		case <-ctx.Done():
+			fail(ctx.Err())
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
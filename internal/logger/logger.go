@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -48,8 +49,13 @@ func GetLogger() Logger {
 	return globalLogger
 }
 
-// InitLogger initializes logger
+// InitLogger initializes global logger
 func InitLogger(logLevel string) {
+	globalLogger = New(logLevel, os.Stderr)
+}
+
+// New creates a logger writing to out, independent of the global logger
+func New(logLevel string, out io.Writer) Logger {
 	logger := logrus.New()
 
 	// Set log format
@@ -59,6 +65,9 @@ func InitLogger(logLevel string) {
 		ForceColors:     true,
 	})
 
+	// Set output
+	logger.SetOutput(out)
+
 	// Set log level
 	if logLevel != "" {
 		level, err := logrus.ParseLevel(logLevel)
@@ -75,17 +84,24 @@ func InitLogger(logLevel string) {
 		logger.SetLevel(logrus.InfoLevel)
 	}
 
-	// Set output
-	logger.SetOutput(os.Stderr)
-
 	// Create wrapper
-	globalLogger = &logrusLogger{
+	l := &logrusLogger{
 		entry: logrus.NewEntry(logger),
 	}
 
-	globalLogger.WithFields(Fields{
+	l.WithFields(Fields{
 		"level": logger.GetLevel().String(),
 	}).Debug("Logger initialization completed")
+
+	return l
+}
+
+// Discard creates a logger that drops all log entries
+func Discard() Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.PanicLevel)
+	return &logrusLogger{entry: logrus.NewEntry(logger)}
 }
 
 // Debug outputs debug log
@@ -0,0 +1,206 @@
+package nuclei
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"warmy/internal/types"
+)
+
+// protocols protocol sections of a template by key, legacy keys map to their
+// current protocol name
+var protocols = map[string]string{
+	"http":       "http",
+	"requests":   "http",
+	"dns":        "dns",
+	"file":       "file",
+	"network":    "network",
+	"tcp":        "network",
+	"headless":   "headless",
+	"ssl":        "ssl",
+	"websocket":  "websocket",
+	"whois":      "whois",
+	"code":       "code",
+	"javascript": "javascript",
+}
+
+// Template parsed nuclei template
+type Template struct {
+	Info     types.TemplateInfo
+	matchers string // Canonical JSON of the matchers of every protocol section
+}
+
+// document top level fields of a template file
+type document struct {
+	ID   string `yaml:"id"`
+	Info *struct {
+		Name           string     `yaml:"name"`
+		Severity       string     `yaml:"severity"`
+		Tags           stringList `yaml:"tags"`
+		Classification struct {
+			CVEID     stringList `yaml:"cve-id"`
+			CWEID     stringList `yaml:"cwe-id"`
+			CVSSScore score      `yaml:"cvss-score"`
+		} `yaml:"classification"`
+	} `yaml:"info"`
+}
+
+// stringList list written either as a YAML sequence or as a comma separated string
+type stringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	var items []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		items = strings.Split(value.Value, ",")
+	case yaml.SequenceNode:
+		for _, node := range value.Content {
+			items = append(items, node.Value)
+		}
+	}
+
+	*l = nil
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// score CVSS score, written as a number or a string
+type score float64
+
+// UnmarshalYAML implements yaml.Unmarshaler, a value that is not a number is ignored
+func (s *score) UnmarshalYAML(value *yaml.Node) error {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(value.Value), 64); err == nil {
+		*s = score(f)
+	}
+	return nil
+}
+
+// IsTemplatePath reports whether a path may hold a nuclei template
+func IsTemplatePath(path string) bool {
+	ext := strings.ToLower(types.GetFileExtension(path))
+	return ext == "yaml" || ext == "yml"
+}
+
+// Parse parses a nuclei template. It returns nil without error for YAML that is
+// not a template, i.e. that has no id or no info section.
+func Parse(content []byte) (*Template, error) {
+	var doc document
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.ID == "" || doc.Info == nil {
+		return nil, nil
+	}
+
+	var sections map[string]any
+	if err := yaml.Unmarshal(content, &sections); err != nil {
+		return nil, err
+	}
+
+	t := &Template{
+		Info: types.TemplateInfo{
+			ID:        doc.ID,
+			Name:      doc.Info.Name,
+			Severity:  strings.ToLower(strings.TrimSpace(doc.Info.Severity)),
+			Tags:      doc.Info.Tags,
+			CVEIDs:    doc.Info.Classification.CVEID,
+			CWEIDs:    doc.Info.Classification.CWEID,
+			CVSSScore: float64(doc.Info.Classification.CVSSScore),
+		},
+	}
+
+	// Matchers are collected per protocol, in the order of the requests of each section
+	matchers := make(map[string][]any)
+	for key, section := range sections {
+		protocol, ok := protocols[key]
+		if !ok {
+			continue
+		}
+		if !types.Contains(t.Info.Protocols, protocol) {
+			t.Info.Protocols = append(t.Info.Protocols, protocol)
+		}
+		requests, _ := section.([]any)
+		for _, request := range requests {
+			fields, _ := request.(map[string]any)
+			if list, ok := fields["matchers"].([]any); ok {
+				t.Info.Matchers += len(list)
+			}
+			matchers[key] = append(matchers[key], []any{fields["matchers-condition"], fields["matchers"]})
+		}
+	}
+	sort.Strings(t.Info.Protocols)
+
+	// Maps are marshaled with sorted keys, so equal matchers give equal JSON
+	canonical, err := json.Marshal(matchers)
+	if err != nil {
+		return nil, err
+	}
+	t.matchers = string(canonical)
+
+	return t, nil
+}
+
+// Compare reports the template level changes between two versions of a file,
+// nil for either side if it is not a template. It returns nil if neither is.
+func Compare(before, after *Template) *types.TemplateChange {
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		info := after.Info
+		return &types.TemplateChange{Changes: []string{types.TemplateNew}, Template: &info}
+	case after == nil:
+		info := before.Info
+		return &types.TemplateChange{Changes: []string{types.TemplateRemoved}, Template: &info}
+	}
+
+	info := after.Info
+	change := &types.TemplateChange{Changes: []string{}, Template: &info}
+	if before.Info.ID != after.Info.ID {
+		change.OldID = before.Info.ID
+	}
+	if before.Info.Severity != after.Info.Severity {
+		change.Changes = append(change.Changes, types.TemplateSeverityChanged)
+		change.OldSeverity = before.Info.Severity
+	}
+	if before.matchers != after.matchers {
+		change.Changes = append(change.Changes, types.TemplateMatcherChanged)
+	}
+	change.AddedTags = missing(after.Info.Tags, before.Info.Tags)
+	change.RemovedTags = missing(before.Info.Tags, after.Info.Tags)
+	if len(change.AddedTags) > 0 || len(change.RemovedTags) > 0 {
+		change.Changes = append(change.Changes, types.TemplateTagsChanged)
+	}
+	if !sameItems(before.Info.CVEIDs, after.Info.CVEIDs) || !sameItems(before.Info.CWEIDs, after.Info.CWEIDs) ||
+		before.Info.CVSSScore != after.Info.CVSSScore {
+		change.Changes = append(change.Changes, types.TemplateClassificationChanged)
+	}
+
+	return change
+}
+
+// missing returns the items of a that are not in b, ignoring case
+func missing(a, b []string) []string {
+	var result []string
+	for _, item := range a {
+		if !slices.ContainsFunc(b, func(other string) bool { return strings.EqualFold(item, other) }) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// sameItems reports whether two lists hold the same items, ignoring case and order
+func sameItems(a, b []string) bool {
+	return len(missing(a, b)) == 0 && len(missing(b, a)) == 0
+}
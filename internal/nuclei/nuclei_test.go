@@ -0,0 +1,136 @@
+package nuclei
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"warmy/internal/types"
+)
+
+// loadTemplate parses testdata/<name>, nil if the file does not exist
+func loadTemplate(t *testing.T, name string) *Template {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return template
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		fixture         string // Fixture pair testdata/<fixture>.before.yaml and .after.yaml
+		wantNil         bool
+		wantChanges     []string
+		wantID          string
+		wantOldID       string
+		wantSeverity    string
+		wantOldSeverity string
+		wantAddedTags   []string
+		wantRemovedTags []string
+	}{
+		{fixture: "not-template", wantNil: true},
+		{fixture: "new", wantChanges: []string{types.TemplateNew}, wantID: "example-panel", wantSeverity: "medium"},
+		{fixture: "removed", wantChanges: []string{types.TemplateRemoved}, wantID: "example-panel", wantSeverity: "medium"},
+		{fixture: "unchanged", wantChanges: []string{}, wantID: "example-panel", wantSeverity: "medium"},
+		{
+			fixture:         "severity",
+			wantChanges:     []string{types.TemplateSeverityChanged},
+			wantID:          "example-panel",
+			wantSeverity:    "high",
+			wantOldSeverity: "medium",
+		},
+		{fixture: "matcher", wantChanges: []string{types.TemplateMatcherChanged}, wantID: "example-panel", wantSeverity: "medium"},
+		{
+			fixture:         "tags",
+			wantChanges:     []string{types.TemplateTagsChanged},
+			wantID:          "example-panel",
+			wantSeverity:    "medium",
+			wantAddedTags:   []string{"login", "exposure"},
+			wantRemovedTags: []string{"admin"},
+		},
+		{fixture: "tags-case", wantChanges: []string{}, wantID: "example-panel", wantSeverity: "medium"},
+		{fixture: "classification", wantChanges: []string{types.TemplateClassificationChanged}, wantID: "example-panel", wantSeverity: "medium"},
+		{fixture: "renamed", wantChanges: []string{}, wantID: "example-admin-panel", wantOldID: "example-panel", wantSeverity: "medium"},
+		{
+			fixture: "all",
+			wantChanges: []string{
+				types.TemplateSeverityChanged,
+				types.TemplateMatcherChanged,
+				types.TemplateTagsChanged,
+				types.TemplateClassificationChanged,
+			},
+			wantID:          "example-panel",
+			wantSeverity:    "critical",
+			wantOldSeverity: "low",
+			wantAddedTags:   []string{"rce"},
+			wantRemovedTags: []string{"admin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			change := Compare(loadTemplate(t, tt.fixture+".before.yaml"), loadTemplate(t, tt.fixture+".after.yaml"))
+			if tt.wantNil {
+				if change != nil {
+					t.Errorf("Compare() = %+v, want nil", change)
+				}
+				return
+			}
+			if change == nil {
+				t.Fatal("Compare() = nil")
+			}
+
+			if !reflect.DeepEqual(change.Changes, tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", change.Changes, tt.wantChanges)
+			}
+			if change.Template.ID != tt.wantID || change.OldID != tt.wantOldID {
+				t.Errorf("id = %q, old id %q, want %q, %q", change.Template.ID, change.OldID, tt.wantID, tt.wantOldID)
+			}
+			if change.Template.Severity != tt.wantSeverity || change.OldSeverity != tt.wantOldSeverity {
+				t.Errorf("severity = %q, old severity %q, want %q, %q",
+					change.Template.Severity, change.OldSeverity, tt.wantSeverity, tt.wantOldSeverity)
+			}
+			if !reflect.DeepEqual(change.AddedTags, tt.wantAddedTags) || !reflect.DeepEqual(change.RemovedTags, tt.wantRemovedTags) {
+				t.Errorf("added tags %v, removed tags %v, want %v, %v",
+					change.AddedTags, change.RemovedTags, tt.wantAddedTags, tt.wantRemovedTags)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	template := loadTemplate(t, "classification.after.yaml")
+	want := types.TemplateInfo{
+		ID:        "example-panel",
+		Name:      "Example Admin Panel",
+		Severity:  "medium",
+		Tags:      []string{"panel", "admin"},
+		CVEIDs:    []string{"CVE-2024-1234"},
+		CWEIDs:    []string{"CWE-79", "CWE-80"},
+		CVSSScore: 6.1,
+		Protocols: []string{"http"},
+		Matchers:  2,
+	}
+	if !reflect.DeepEqual(template.Info, want) {
+		t.Errorf("Parse() info = %+v, want %+v", template.Info, want)
+	}
+
+	if template := loadTemplate(t, "not-template.after.yaml"); template != nil {
+		t.Errorf("Parse() of a workflow = %+v, want nil", template.Info)
+	}
+	if _, err := Parse([]byte("id: [unterminated")); err == nil {
+		t.Error("Parse() of invalid YAML succeeded")
+	}
+}
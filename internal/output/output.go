@@ -0,0 +1,36 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveJSONToFile saves JSON to file and returns the full file path
+func SaveJSONToFile(dir, filename, data string) (string, error) {
+	return SaveToFile(dir, filename, data)
+}
+
+// SaveToFile saves data to file, creating the directory if needed, and returns the full file path
+func SaveToFile(dir, filename, data string) (string, error) {
+	// Ensure directory exists
+	if dir != "." && dir != "" {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	// Build complete file path
+	fullPath := filename
+	if dir != "." && dir != "" {
+		fullPath = filepath.Join(dir, filename)
+	}
+
+	err := os.WriteFile(fullPath, []byte(data), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to save to file: %w", err)
+	}
+
+	return fullPath, nil
+}
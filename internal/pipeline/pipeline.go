@@ -0,0 +1,407 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	wgit "warmy/internal/git"
+	"warmy/internal/logger"
+	"warmy/internal/output"
+	"warmy/internal/types"
+)
+
+// Options pipeline options
+type Options struct {
+	RepoPath    string    // Local repository path
+	CommitRange string    // Commit range, see ListCommits
+	MaxCommits  int       // Maximum number of commits, 0 means no limit
+	Workers     int       // Number of commit workers, 0 means number of CPUs
+	Progress    io.Writer // Progress indicator output, nil disables the indicator
+}
+
+// Result analysis result of one commit of the range
+type Result struct {
+	Index      int               // Position in the range, 0 is the newest commit
+	Total      int               // Number of commits in the range
+	Unlisted   int               // Skipped commits of the range not passed on, see ListCommits
+	Hash       plumbing.Hash     // Commit hash
+	CommitInfo *types.CommitInfo // Commit information, nil if analysis failed or the commit was skipped
+	Skipped    string            // Reason the commit filters skipped the commit, empty if analyzed
+	Err        error             // Analysis error
+}
+
+// RangeCommit commit of a range
+type RangeCommit struct {
+	Hash    plumbing.Hash
+	Skipped string // Reason the commit filters skip the commit, empty if it is analyzed
+}
+
+// job commit to analyze with its position in the range
+type job struct {
+	seq    int
+	commit RangeCommit
+}
+
+// Execute analyzes every commit of the range and passes the results to handle
+// in range order (newest first).
+// Commits are resolved, diffed and focus checked on a pool of workers. The number
+// of commits waiting for handle is bounded, so a slow handler applies back-pressure
+// to the workers. Canceling ctx, or handle returning an error, stops feeding new
+// commits; the error is returned after the running workers have finished.
+func Execute(ctx context.Context, analyzer *wgit.Analyzer, opts Options, handle func(Result) error) error {
+	log := analyzer.Logger()
+
+	repo, err := git.PlainOpen(opts.RepoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local repository: %w", err)
+	}
+
+	commits, unlisted, err := ListCommits(analyzer, repo, opts.CommitRange, opts.MaxCommits)
+	if err != nil {
+		return err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(commits) {
+		workers = len(commits)
+	}
+
+	log.WithFields(logger.Fields{
+		"repo_path":    opts.RepoPath,
+		"commit_range": opts.CommitRange,
+		"commits":      len(commits),
+		"unlisted":     unlisted,
+		"workers":      workers,
+	}).Info("Started range analysis")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan job)
+	results := make(chan Result, workers)
+	// Slots bound the commits in flight between feeding and handling
+	slots := make(chan struct{}, workers*2)
+
+	// Stage 1: feed commits in range order
+	go func() {
+		defer close(jobs)
+		for seq, commit := range commits {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job{seq: seq, commit: commit}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Stage 2: resolve, diff and focus check commits, skipped commits are passed on as they are
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every worker reads through its own repository instance
+			workerRepo, openErr := git.PlainOpen(opts.RepoPath)
+
+			for j := range jobs {
+				r := Result{Index: j.seq, Total: len(commits), Unlisted: unlisted, Hash: j.commit.Hash, Skipped: j.commit.Skipped}
+				if r.Skipped == "" {
+					r.Err = openErr
+					if openErr == nil {
+						r.CommitInfo, r.Err = analyzeCommit(ctx, analyzer, workerRepo, j.commit.Hash)
+					}
+				}
+
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Stage 3: hand results over in range order
+	progress := newProgress(opts.Progress, len(commits))
+	pending := make(map[int]Result)
+	next := 0
+	failed := 0
+	var handleErr error
+
+	for r := range results {
+		if handleErr != nil {
+			continue
+		}
+
+		pending[r.Index] = r
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+
+			if p.Err != nil {
+				failed++
+			}
+			if err := handle(p); err != nil {
+				handleErr = err
+				cancel()
+				break
+			}
+
+			<-slots
+			next++
+			progress.update(next, failed)
+		}
+	}
+	progress.done()
+
+	if handleErr != nil {
+		return handleErr
+	}
+	if next < len(commits) {
+		log.WithFields(logger.Fields{
+			"analyzed": next,
+			"total":    len(commits),
+		}).Warn("Range analysis canceled")
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Run analyzes the commit range of the analyzer configuration, writes a report
+// per commit and a combined summary.
+// On cancellation the summary of the analyzed commits is still saved and returned.
+func Run(ctx context.Context, analyzer *wgit.Analyzer) (*types.RangeSummary, error) {
+	cfg := analyzer.Config()
+	log := analyzer.Logger()
+
+	summary := &types.RangeSummary{
+		RepoPath:    cfg.RepoPath,
+		CommitRange: cfg.CommitRange,
+		AnalyzeTime: time.Now().Format("20060102-150405"),
+		Commits:     make([]types.RangeCommitResult, 0),
+	}
+
+	opts := Options{
+		RepoPath:    cfg.RepoPath,
+		CommitRange: cfg.CommitRange,
+		MaxCommits:  cfg.MaxCommits,
+		Workers:     cfg.CommitWorkers,
+	}
+	if cfg.Progress.Value() {
+		opts.Progress = os.Stderr
+	}
+
+	err := Execute(ctx, analyzer, opts, func(r Result) error {
+		summary.TotalCommits = r.Total + r.Unlisted
+		summary.UnlistedSkipped = r.Unlisted
+
+		commitResult := writeResult(analyzer, r)
+		summary.Commits = append(summary.Commits, commitResult)
+		switch {
+		case commitResult.Skipped != "":
+			summary.SkippedCommits++
+		case commitResult.Error != "":
+			summary.FailedCommits++
+		default:
+			summary.SuccessCommits++
+			summary.FocusStats.Add(commitResult.FocusStats)
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			return nil, err
+		}
+		summary.Canceled = true
+	}
+	summary.SkippedCommits += summary.UnlistedSkipped
+
+	// Save combined summary
+	if !cfg.NoFile.Value() {
+		filename := fmt.Sprintf("range-summary-%s.json", summary.AnalyzeTime)
+		summary.OutputFile = filename
+
+		jsonOutput, err := summary.ToJSON(cfg.PrettyJSON.Value())
+		if err != nil {
+			return summary, fmt.Errorf("failed to format summary JSON: %w", err)
+		}
+
+		fullPath, err := output.SaveJSONToFile(cfg.OutputDir, filename, jsonOutput)
+		if err != nil {
+			return summary, err
+		}
+
+		log.WithFields(logger.Fields{
+			"filename": filename,
+			"filepath": fullPath,
+		}).Info("Range summary saved to file")
+	}
+
+	log.WithFields(logger.Fields{
+		"total_commits":     summary.TotalCommits,
+		"success_commits":   summary.SuccessCommits,
+		"failed_commits":    summary.FailedCommits,
+		"skipped_commits":   summary.SkippedCommits,
+		"total_focus_files": summary.FocusStats.TotalFocusFiles,
+	}).Info("Range analysis completed")
+
+	return summary, nil
+}
+
+// analyzeCommit loads and analyzes a single commit
+func analyzeCommit(ctx context.Context, analyzer *wgit.Analyzer, repo *git.Repository, hash plumbing.Hash) (*types.CommitInfo, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit object: %w", err)
+	}
+	return analyzer.AnalyzeCommit(ctx, repo, commit)
+}
+
+// writeResult saves the report of an analyzed commit and returns its summary entry.
+// Skipped commits have no report, their entry only holds the reason.
+func writeResult(analyzer *wgit.Analyzer, r Result) types.RangeCommitResult {
+	cfg := analyzer.Config()
+	commitResult := types.RangeCommitResult{
+		Hash:      r.Hash.String(),
+		ShortHash: r.Hash.String()[:8],
+		Skipped:   r.Skipped,
+	}
+	if r.Skipped != "" {
+		return commitResult
+	}
+	if r.Err != nil {
+		commitResult.Error = r.Err.Error()
+		return commitResult
+	}
+
+	commitInfo := r.CommitInfo
+	commitResult.Message = commitInfo.Message
+	commitResult.Author = commitInfo.Author.Name
+	commitResult.Timestamp = commitInfo.Timestamp
+	commitResult.Stats = commitInfo.Stats
+	commitResult.FocusStats = commitInfo.FocusStats
+
+	if cfg.NoFile.Value() {
+		return commitResult
+	}
+
+	filename := fmt.Sprintf("%s-%s.json", commitInfo.ShortHash, commitInfo.AnalyzeTime)
+	commitInfo.OutputFile = filename
+
+	jsonOutput, err := commitInfo.ToJSON(cfg.PrettyJSON.Value())
+	if err != nil {
+		commitResult.Error = fmt.Sprintf("failed to format JSON: %v", err)
+		return commitResult
+	}
+
+	fullPath, err := output.SaveJSONToFile(cfg.OutputDir, filename, jsonOutput)
+	if err != nil {
+		commitResult.Error = err.Error()
+		return commitResult
+	}
+	commitResult.OutputFile = fullPath
+
+	return commitResult
+}
+
+// maxListedSkipped maximum number of skipped commits listed by ListCommits
+const maxListedSkipped = 1000
+
+// ListCommits lists commits of a range, newest first.
+// "A..B" means commits reachable from B but not from A, an omitted side is HEAD like
+// in git, a single revision means its whole history, and an empty range means the
+// history of HEAD. Symmetric differences ("A...B") are not supported.
+// Commits are checked against the commit filters, skipped commits are listed with
+// the reason and do not count towards maxCommits. Only the first maxListedSkipped
+// skipped commits are listed, the number of further skipped commits is returned.
+func ListCommits(analyzer *wgit.Analyzer, repo *git.Repository, commitRange string, maxCommits int) ([]RangeCommit, int, error) {
+	if strings.Contains(commitRange, "...") {
+		return nil, 0, fmt.Errorf("unsupported range syntax %q: symmetric differences (A...B) are not supported, use A..B", commitRange)
+	}
+
+	from, to := "", commitRange
+	if start, end, found := strings.Cut(commitRange, ".."); found {
+		from, to = start, end
+		if from == "" {
+			from = "HEAD"
+		}
+	}
+
+	toCommit, err := analyzer.ResolveCommit(repo, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Exclude everything reachable from the start of the range
+	excluded := make(map[plumbing.Hash]bool)
+	if from != "" {
+		fromCommit, err := analyzer.ResolveCommit(repo, from)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		iter := object.NewCommitPreorderIter(fromCommit, nil, nil)
+		err = iter.ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to walk commit history: %w", err)
+		}
+	}
+
+	commits := make([]RangeCommit, 0)
+	analyzed, listedSkipped, unlisted := 0, 0, 0
+	iter := object.NewCommitIterCTime(toCommit, excluded, nil)
+	defer iter.Close()
+	for maxCommits <= 0 || analyzed < maxCommits {
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to walk commit history: %w", err)
+		}
+
+		skipped := analyzer.SkipCommit(c)
+		switch {
+		case skipped == "":
+			analyzed++
+		case listedSkipped >= maxListedSkipped:
+			unlisted++
+			continue
+		default:
+			listedSkipped++
+		}
+		commits = append(commits, RangeCommit{Hash: c.Hash, Skipped: skipped})
+	}
+
+	return commits, unlisted, nil
+}
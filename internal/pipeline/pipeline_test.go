@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"warmy/internal/config"
+	wgit "warmy/internal/git"
+	"warmy/internal/logger"
+)
+
+// storeObject encodes an object into the repository storage
+func storeObject(t *testing.T, repo *git.Repository, encode func(plumbing.EncodedObject) error) plumbing.Hash {
+	t.Helper()
+	obj := repo.Storer.NewEncodedObject()
+	if err := encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestListCommits(t *testing.T) {
+	// c1 - c2 - c3 - c4   master, HEAD
+	//        \
+	//         s1          side
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := storeObject(t, repo, (&object.Tree{}).Encode)
+	names := make(map[plumbing.Hash]string)
+	newCommit := func(name string, parents ...plumbing.Hash) plumbing.Hash {
+		author := object.Signature{Name: "A", Email: "a@example.com", When: time.Unix(1700000000+int64(len(names)), 0).UTC()}
+		commit := &object.Commit{Author: author, Committer: author, Message: name + "\n", TreeHash: tree, ParentHashes: parents}
+		hash := storeObject(t, repo, commit.Encode)
+		names[hash] = name
+		return hash
+	}
+	setRef := func(name plumbing.ReferenceName, hash plumbing.Hash) {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c1 := newCommit("c1")
+	c2 := newCommit("c2", c1)
+	c3 := newCommit("c3", c2)
+	c4 := newCommit("c4", c3)
+	s1 := newCommit("s1", c2)
+	setRef(plumbing.NewBranchReferenceName("master"), c4)
+	setRef(plumbing.NewBranchReferenceName("side"), s1)
+	setRef(plumbing.NewTagReferenceName("v1"), c2)
+
+	cfg := config.DefaultConfig()
+	analyzer, err := wgit.New(&cfg, logger.New("error", io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		commitRange string
+		maxCommits  int
+		want        string
+		wantErr     string
+	}{
+		{commitRange: "", want: "c4,c3,c2,c1"},
+		{commitRange: "", maxCommits: 2, want: "c4,c3"},
+		{commitRange: "side", want: "s1,c2,c1"},
+		{commitRange: "v1..master", want: "c4,c3"},
+		{commitRange: "v1..", want: "c4,c3"},
+		{commitRange: "..side", want: "s1"},
+		{commitRange: "side..master", want: "c4,c3"},
+		{commitRange: "master..v1", want: ""},
+		{commitRange: "master...side", wantErr: `unsupported range syntax "master...side"`},
+		{commitRange: "...side", wantErr: "unsupported range syntax"},
+		{commitRange: "missing..master", wantErr: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q/%d", tt.commitRange, tt.maxCommits), func(t *testing.T) {
+			commits, unlisted, err := ListCommits(analyzer, repo, tt.commitRange, tt.maxCommits)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, c := range commits {
+				got = append(got, names[c.Hash])
+			}
+			if strings.Join(got, ",") != tt.want || unlisted != 0 {
+				t.Errorf("ListCommits() = %v, %d unlisted, want %s", got, unlisted, tt.want)
+			}
+		})
+	}
+}
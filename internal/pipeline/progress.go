@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+)
+
+// progress renders a single line progress indicator
+type progress struct {
+	out     io.Writer
+	total   int
+	enabled bool
+}
+
+// newProgress creates a progress indicator writing to out, a nil out disables it
+func newProgress(out io.Writer, total int) *progress {
+	return &progress{
+		out:     out,
+		total:   total,
+		enabled: out != nil && total > 0,
+	}
+}
+
+// update redraws the progress line
+func (p *progress) update(finished, failed int) {
+	if !p.enabled {
+		return
+	}
+
+	percent := finished * 100 / p.total
+	if failed > 0 {
+		fmt.Fprintf(p.out, "\rAnalyzing commits: %d/%d (%d%%), %d failed", finished, p.total, percent, failed)
+	} else {
+		fmt.Fprintf(p.out, "\rAnalyzing commits: %d/%d (%d%%)", finished, p.total, percent)
+	}
+}
+
+// done terminates the progress line
+func (p *progress) done() {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprintln(p.out)
+}
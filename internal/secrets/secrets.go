@@ -0,0 +1,288 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// Built-in detector names
+const (
+	DetectorAWSAccessKey = "aws_access_key"
+	DetectorAWSSecretKey = "aws_secret_key"
+	DetectorPrivateKey   = "private_key"
+	DetectorGitHubToken  = "github_token"
+	DetectorJWT          = "jwt"
+	DetectorHighEntropy  = "high_entropy"
+)
+
+// detector named secret pattern, the first capture group is the secret if the
+// pattern has one, otherwise the whole match
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// builtinDetectors regular expression detectors built into the scanner, high
+// entropy strings and private key blocks are detected separately
+var builtinDetectors = []detector{
+	{DetectorAWSAccessKey, regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`)},
+	{DetectorAWSSecretKey, regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?[=:"'\s]\s*["']?([A-Za-z0-9/+]{40})\b`)},
+	{DetectorGitHubToken, regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{DetectorJWT, regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`)},
+}
+
+var (
+	// privateKeyBegin matches the header line of a PEM or OpenSSH private key
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN ((?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?)-----`)
+	// privateKeyEnd matches the footer line of a private key
+	privateKeyEnd = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)
+	// entropyCandidate matches strings checked for high entropy
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`)
+)
+
+// Scanner detects secrets in added lines and redacts them from diffs.
+// A Scanner is immutable after creation and safe for concurrent use.
+type Scanner struct {
+	enabled     bool
+	redact      bool
+	detectors   []detector // Regular expression detectors, built-in and custom
+	privateKeys bool       // Whether the private_key detector is enabled
+	highEntropy bool       // Whether the high_entropy detector is enabled
+	minEntropy  float64    // Minimum Shannon entropy of high entropy strings (bits per character)
+}
+
+// New creates a secret scanner from the secrets configuration
+func New(cfg config.SecretsConfig) (*Scanner, error) {
+	s := &Scanner{
+		enabled:    cfg.Enable.Value(),
+		redact:     cfg.Enable.Value() && cfg.Redact.Value(),
+		minEntropy: cfg.MinEntropy,
+	}
+	if !s.enabled {
+		return s, nil
+	}
+
+	enabled := func(name string) bool {
+		return len(cfg.Detectors) == 0 || types.Contains(cfg.Detectors, name)
+	}
+	for _, name := range cfg.Detectors {
+		switch name {
+		case DetectorAWSAccessKey, DetectorAWSSecretKey, DetectorPrivateKey, DetectorGitHubToken, DetectorJWT, DetectorHighEntropy:
+		default:
+			return nil, fmt.Errorf("unknown secret detector: %s", name)
+		}
+	}
+
+	for _, d := range builtinDetectors {
+		if enabled(d.name) {
+			s.detectors = append(s.detectors, d)
+		}
+	}
+	s.privateKeys = enabled(DetectorPrivateKey)
+	s.highEntropy = enabled(DetectorHighEntropy)
+
+	for _, custom := range cfg.Custom {
+		re, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile secret detector %s pattern: %s, error: %v", custom.Name, custom.Pattern, err)
+		}
+		s.detectors = append(s.detectors, detector{name: custom.Name, pattern: re})
+	}
+
+	return s, nil
+}
+
+// Enabled reports whether secret scanning is enabled
+func (s *Scanner) Enabled() bool {
+	return s.enabled
+}
+
+// File returns the scanner of the lines of one file
+func (s *Scanner) File(path string) *FileScanner {
+	return &FileScanner{scanner: s, path: path}
+}
+
+// FileScanner scans the lines of one file in order. Private keys span several
+// lines, so the added lines and the lines passed to Redact are tracked separately.
+// A FileScanner is not safe for concurrent use.
+type FileScanner struct {
+	scanner       *Scanner
+	path          string
+	findings      []types.SecretFinding
+	addedInKey    bool // Whether the last added line was inside a private key block
+	redactedInKey bool // Whether the last redacted line was inside a private key block
+}
+
+// Added scans an added line, lineNo is its line number in the new file. It returns
+// the line with secrets redacted if redaction is enabled.
+func (f *FileScanner) Added(lineNo int, line string) string {
+	if !f.scanner.enabled {
+		return line
+	}
+
+	matches := f.scanner.match(line, &f.addedInKey)
+	for _, m := range matches {
+		finding := types.SecretFinding{Detector: m.detector, File: f.path, Line: lineNo, Match: preview(m.secret)}
+		if m.detector == DetectorPrivateKey {
+			// Only the header of a private key is reported, it reveals nothing
+			if m.header == "" {
+				continue
+			}
+			finding.Match = m.header
+		}
+		f.findings = append(f.findings, finding)
+	}
+
+	if f.scanner.redact {
+		return redactLine(line, matches)
+	}
+	return line
+}
+
+// Redact returns a line with secrets redacted if redaction is enabled, without
+// reporting findings. It is used for deleted lines, which leak secrets as well.
+func (f *FileScanner) Redact(line string) string {
+	if !f.scanner.redact {
+		return line
+	}
+	return redactLine(line, f.scanner.match(line, &f.redactedInKey))
+}
+
+// Findings returns the secrets found in the added lines
+func (f *FileScanner) Findings() []types.SecretFinding {
+	return f.findings
+}
+
+// match secret found in a line
+type match struct {
+	detector   string
+	secret     string // Secret text
+	start, end int    // Position of the secret in the line
+	header     string // Private key header, set on the line starting a private key
+}
+
+// match finds the secrets of a line. inKey tracks whether the line is part of a
+// private key block, whose lines are secret as a whole.
+func (s *Scanner) match(line string, inKey *bool) []match {
+	var matches []match
+
+	if s.privateKeys {
+		if *inKey {
+			if loc := privateKeyEnd.FindStringIndex(line); loc != nil {
+				*inKey = false
+				if loc[0] > 0 {
+					matches = append(matches, match{detector: DetectorPrivateKey, secret: line[:loc[0]], start: 0, end: loc[0]})
+				}
+				return matches
+			}
+			return append(matches, match{detector: DetectorPrivateKey, secret: line, start: 0, end: len(line)})
+		}
+		if m := privateKeyBegin.FindStringSubmatchIndex(line); m != nil {
+			*inKey = !privateKeyEnd.MatchString(line[m[1]:])
+			matches = append(matches, match{
+				detector: DetectorPrivateKey,
+				secret:   line[m[1]:],
+				start:    m[1],
+				end:      len(line),
+				header:   line[m[0]:m[1]],
+			})
+		}
+	}
+
+	for _, d := range s.detectors {
+		for _, loc := range d.pattern.FindAllStringSubmatchIndex(line, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start < end && !overlaps(matches, start, end) {
+				matches = append(matches, match{detector: d.name, secret: line[start:end], start: start, end: end})
+			}
+		}
+	}
+
+	if s.highEntropy {
+		for _, loc := range entropyCandidate.FindAllStringIndex(line, -1) {
+			candidate := line[loc[0]:loc[1]]
+			if overlaps(matches, loc[0], loc[1]) || !isMixed(candidate) || entropy(candidate) < s.minEntropy {
+				continue
+			}
+			matches = append(matches, match{detector: DetectorHighEntropy, secret: candidate, start: loc[0], end: loc[1]})
+		}
+	}
+
+	return matches
+}
+
+// overlaps reports whether the range start:end overlaps any match
+func overlaps(matches []match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// isMixed reports whether s contains both letters and digits, words and
+// identifiers rarely do while keys and tokens almost always do
+func isMixed(s string) bool {
+	letter, digit := false, false
+	for _, r := range s {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// preview returns the redacted form of a secret reported in findings, keeping
+// up to four leading characters
+func preview(secret string) string {
+	keep := min(4, len(secret)/4)
+	return secret[:keep] + strings.Repeat("*", 8)
+}
+
+// redactLine replaces the secrets of a line by a redaction marker
+func redactLine(line string, matches []match) string {
+	if len(matches) == 0 {
+		return line
+	}
+
+	sorted := append([]match(nil), matches...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, m := range sorted {
+		if m.start == m.end {
+			continue
+		}
+		b.WriteString(line[last:m.start])
+		b.WriteString("[REDACTED:" + m.detector + "]")
+		last = m.end
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package severity
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// Tracker detects changes of a severity field in structured files and classifies
+// them on an ordered severity scale.
+// A Tracker is immutable after creation and safe for concurrent use.
+type Tracker struct {
+	enabled bool
+	field   string
+	path    []string // Keys of the field, outermost first
+	scale   []string // Lower cased severities, lowest first
+}
+
+// New creates a severity tracker from the severity tracking configuration
+func New(cfg config.SeverityTrackingConfig) (*Tracker, error) {
+	t := &Tracker{enabled: cfg.Enable.Value(), field: cfg.Field}
+	if !t.enabled {
+		return t, nil
+	}
+
+	for _, key := range strings.Split(cfg.Field, ".") {
+		if key == "" {
+			return nil, fmt.Errorf("invalid severity_tracking.field %q", cfg.Field)
+		}
+		t.path = append(t.path, key)
+	}
+
+	if len(cfg.Scale) == 0 {
+		return nil, fmt.Errorf("severity_tracking.scale must not be empty")
+	}
+	for _, level := range cfg.Scale {
+		level = strings.ToLower(strings.TrimSpace(level))
+		if types.Contains(t.scale, level) {
+			return nil, fmt.Errorf("duplicate severity %q in severity_tracking.scale", level)
+		}
+		t.scale = append(t.scale, level)
+	}
+
+	return t, nil
+}
+
+// Tracks reports whether severity changes of a file are tracked, only YAML and
+// JSON files are
+func (t *Tracker) Tracks(path string) bool {
+	if !t.enabled {
+		return false
+	}
+	switch strings.ToLower(types.GetFileExtension(path)) {
+	case "yaml", "yml", "json":
+		return true
+	}
+	return false
+}
+
+// Compare reports the change of the severity field between the content of a file
+// before and after a change, nil if the severity did not change, a side has no
+// severity field or cannot be parsed
+func (t *Tracker) Compare(path string, before, after []byte) *types.SeverityChange {
+	from, ok := t.value(before)
+	if !ok {
+		return nil
+	}
+	to, ok := t.value(after)
+	if !ok || from == to {
+		return nil
+	}
+
+	change := &types.SeverityChange{
+		File:        path,
+		Field:       t.field,
+		OldSeverity: from,
+		NewSeverity: to,
+		Direction:   types.SeverityChanged,
+	}
+
+	// Values missing from the scale cannot be ordered
+	fromLevel, toLevel := t.level(from), t.level(to)
+	if fromLevel < 0 || toLevel < 0 {
+		return change
+	}
+	change.Steps = toLevel - fromLevel
+	if change.Steps > 0 {
+		change.Direction = types.SeverityEscalation
+	} else {
+		change.Direction = types.SeverityDeescalation
+	}
+	return change
+}
+
+// value returns the lower cased severity field of YAML or JSON content
+func (t *Tracker) value(content []byte) (string, bool) {
+	var doc any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return "", false
+	}
+
+	for _, key := range t.path {
+		fields, ok := doc.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if doc, ok = fields[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := doc.(type) {
+	case nil, map[string]any, []any:
+		return "", false
+	default:
+		value := strings.ToLower(strings.TrimSpace(fmt.Sprint(v)))
+		return value, value != ""
+	}
+}
+
+// level returns the position of a severity on the scale, -1 if it is not on it
+func (t *Tracker) level(severity string) int {
+	for i, level := range t.scale {
+		if level == severity {
+			return i
+		}
+	}
+	return -1
+}
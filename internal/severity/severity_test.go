@@ -0,0 +1,163 @@
+package severity
+
+import (
+	"strings"
+	"testing"
+
+	"warmy/internal/config"
+	"warmy/internal/types"
+)
+
+// newTestTracker creates an enabled tracker of info.severity on the nuclei scale
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tracker, err := New(config.SeverityTrackingConfig{
+		Enable: config.Bool(true),
+		Field:  "info.severity",
+		Scale:  []string{"info", "low", "medium", "high", "critical"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "yaml", content: "id: a\ninfo:\n  name: A\n  severity: High\n", want: "high", wantOK: true},
+		{name: "json", content: `{"id": "a", "info": {"severity": " Critical "}}`, want: "critical", wantOK: true},
+		{name: "yaml flow mapping", content: "info: {severity: low}\n", want: "low", wantOK: true},
+		{name: "number", content: "info:\n  severity: 3\n", want: "3", wantOK: true},
+		{name: "missing field", content: "info:\n  name: A\n", wantOK: false},
+		{name: "missing parent", content: "id: a\nseverity: high\n", wantOK: false},
+		{name: "parent not a mapping", content: "info: high\n", wantOK: false},
+		{name: "null", content: "info:\n  severity:\n", wantOK: false},
+		{name: "empty", content: "info:\n  severity: \"\"\n", wantOK: false},
+		{name: "mapping", content: "info:\n  severity:\n    level: high\n", wantOK: false},
+		{name: "list", content: "info:\n  severity: [high]\n", wantOK: false},
+		{name: "invalid", content: "info: [unterminated\n", wantOK: false},
+	}
+
+	tracker := newTestTracker(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tracker.value([]byte(tt.content))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("value() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	yamlSeverity := func(severity string) string { return "id: a\ninfo:\n  severity: " + severity + "\n" }
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   *types.SeverityChange // File and Field are filled in
+	}{
+		{
+			name:   "escalation",
+			before: yamlSeverity("low"),
+			after:  yamlSeverity("critical"),
+			want:   &types.SeverityChange{OldSeverity: "low", NewSeverity: "critical", Direction: types.SeverityEscalation, Steps: 3},
+		},
+		{
+			name:   "de-escalation",
+			before: yamlSeverity("HIGH"),
+			after:  yamlSeverity("medium"),
+			want:   &types.SeverityChange{OldSeverity: "high", NewSeverity: "medium", Direction: types.SeverityDeescalation, Steps: -1},
+		},
+		{
+			name:   "json escalation",
+			before: `{"info": {"severity": "info"}}`,
+			after:  `{"info": {"severity": "low"}}`,
+			want:   &types.SeverityChange{OldSeverity: "info", NewSeverity: "low", Direction: types.SeverityEscalation, Steps: 1},
+		},
+		{
+			name:   "new value not on the scale",
+			before: yamlSeverity("low"),
+			after:  yamlSeverity("urgent"),
+			want:   &types.SeverityChange{OldSeverity: "low", NewSeverity: "urgent", Direction: types.SeverityChanged},
+		},
+		{
+			name:   "old value not on the scale",
+			before: yamlSeverity("unknown"),
+			after:  yamlSeverity("high"),
+			want:   &types.SeverityChange{OldSeverity: "unknown", NewSeverity: "high", Direction: types.SeverityChanged},
+		},
+		{name: "unchanged", before: yamlSeverity("high"), after: yamlSeverity("High")},
+		{name: "field added", before: "id: a\ninfo: {}\n", after: yamlSeverity("high")},
+		{name: "field removed", before: yamlSeverity("high"), after: "id: a\ninfo: {}\n"},
+		{name: "file added", before: "", after: yamlSeverity("high")},
+		{name: "invalid after", before: yamlSeverity("high"), after: "info: [unterminated\n"},
+	}
+
+	tracker := newTestTracker(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tracker.Compare("templates/a.yaml", []byte(tt.before), []byte(tt.after))
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Compare() = %+v, want nil", got)
+				}
+				return
+			}
+
+			want := *tt.want
+			want.File, want.Field = "templates/a.yaml", "info.severity"
+			if got == nil || *got != want {
+				t.Errorf("Compare() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTracks(t *testing.T) {
+	tracker := newTestTracker(t)
+	for path, want := range map[string]bool{
+		"a.yaml": true, "a.YML": true, "dir/a.json": true, "a.go": false, "yaml": false,
+	} {
+		if got := tracker.Tracks(path); got != want {
+			t.Errorf("Tracks(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	disabled, err := New(config.SeverityTrackingConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.Tracks("a.yaml") {
+		t.Error("disabled tracker tracks a.yaml")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		scale   []string
+		wantErr string
+	}{
+		{name: "empty key", field: "info..severity", scale: []string{"low"}, wantErr: `invalid severity_tracking.field "info..severity"`},
+		{name: "empty field", field: "", scale: []string{"low"}, wantErr: "invalid severity_tracking.field"},
+		{name: "empty scale", field: "severity", wantErr: "severity_tracking.scale must not be empty"},
+		{name: "duplicate severity", field: "severity", scale: []string{"low", "Low"}, wantErr: `duplicate severity "low"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(config.SeverityTrackingConfig{Enable: config.Bool(true), Field: tt.field, Scale: tt.scale})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
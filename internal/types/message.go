@@ -0,0 +1,143 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Well-known trailer keys
+const (
+	TrailerSignedOffBy    = "Signed-off-by"
+	TrailerCoAuthoredBy   = "Co-authored-by"
+	TrailerReviewedBy     = "Reviewed-by"
+	TrailerFixes          = "Fixes"
+	TrailerBreakingChange = "BREAKING CHANGE"
+)
+
+// knownTrailers canonical spelling of well-known trailer keys by lower cased key
+var knownTrailers = map[string]string{
+	"signed-off-by":   TrailerSignedOffBy,
+	"co-authored-by":  TrailerCoAuthoredBy,
+	"reviewed-by":     TrailerReviewedBy,
+	"fixes":           TrailerFixes,
+	"breaking change": TrailerBreakingChange,
+	"breaking-change": TrailerBreakingChange,
+}
+
+var (
+	// conventionalHeader matches a Conventional Commits header: type(scope)!: description
+	conventionalHeader = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+	// trailerLine matches a "Key: value" trailer or a "Key #value" footer
+	trailerLine = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)(?::\s?(.*)| (#.*))$`)
+)
+
+// ConventionalInfo represents a Conventional Commits header and its breaking change footer
+type ConventionalInfo struct {
+	Type           string `json:"type"`                      // Commit type, lower cased
+	Scope          string `json:"scope,omitempty"`           // Commit scope
+	Breaking       bool   `json:"breaking,omitempty"`        // Whether the header has a ! marker or the message a BREAKING CHANGE footer
+	BreakingChange string `json:"breaking_change,omitempty"` // Description of the breaking change
+}
+
+// TrailerInfo represents a git trailer of the commit message
+type TrailerInfo struct {
+	Key   string `json:"key"`   // Trailer key, well-known keys in their canonical spelling
+	Value string `json:"value"` // Trailer value, continuation lines joined by spaces
+}
+
+// CommitMessage represents a parsed commit message
+type CommitMessage struct {
+	Subject      string            // Commit message subject
+	Description  string            // Detailed description
+	FullMessage  string            // Full commit message
+	Conventional *ConventionalInfo // Conventional Commits header, nil if the subject is not one
+	Trailers     []TrailerInfo     // Trailers of the last paragraph
+}
+
+// ParseCommitMessage splits a commit message into subject and description and
+// parses its Conventional Commits header and trailers
+func ParseCommitMessage(message string) CommitMessage {
+	message = strings.TrimSpace(message)
+	subject, description := SplitCommitMessage(message)
+
+	parsed := CommitMessage{
+		Subject:     subject,
+		Description: description,
+		FullMessage: message,
+		Trailers:    ParseTrailers(message),
+	}
+
+	if commitType, scope, breaking, ok := ParseConventionalHeader(subject); ok {
+		parsed.Conventional = &ConventionalInfo{Type: commitType, Scope: scope, Breaking: breaking}
+		if breaking {
+			parsed.Conventional.BreakingChange = conventionalHeader.FindStringSubmatch(subject)[4]
+		}
+		// A BREAKING CHANGE footer describes the change better than the subject
+		if values := TrailerValues(parsed.Trailers, TrailerBreakingChange); len(values) > 0 {
+			parsed.Conventional.Breaking = true
+			parsed.Conventional.BreakingChange = values[0]
+		}
+	}
+
+	return parsed
+}
+
+// ParseConventionalHeader parses type, scope and breaking marker of a Conventional
+// Commits subject. The type is lower cased, ok is false if the subject is not a
+// Conventional Commits header.
+func ParseConventionalHeader(subject string) (commitType, scope string, breaking, ok bool) {
+	m := conventionalHeader.FindStringSubmatch(subject)
+	if m == nil {
+		return "", "", false, false
+	}
+	return strings.ToLower(m[1]), strings.TrimSpace(m[2]), m[3] == "!", true
+}
+
+// ParseTrailers parses the trailers of a commit message like git interpret-trailers.
+// Trailers are taken from the last paragraph if it is not the subject and every line
+// is a trailer, a continuation line indented by whitespace or a cherry-pick note.
+func ParseTrailers(message string) []TrailerInfo {
+	lines := strings.Split(strings.TrimRight(message, " \t\r\n"), "\n")
+
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	if start == 0 {
+		return nil
+	}
+
+	var trailers []TrailerInfo
+	for _, line := range lines[start:] {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case (line[0] == ' ' || line[0] == '\t') && len(trailers) > 0:
+			last := &trailers[len(trailers)-1]
+			last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(line))
+		case strings.HasPrefix(line, "(cherry picked from commit "):
+		default:
+			m := trailerLine.FindStringSubmatch(line)
+			if m == nil {
+				return nil
+			}
+			key := m[1]
+			if canonical, ok := knownTrailers[strings.ToLower(key)]; ok {
+				key = canonical
+			}
+			trailers = append(trailers, TrailerInfo{Key: key, Value: strings.TrimSpace(m[2] + m[3])})
+		}
+	}
+
+	return trailers
+}
+
+// TrailerValues returns the values of the trailers with the given key, ignoring case
+func TrailerValues(trailers []TrailerInfo, key string) []string {
+	var values []string
+	for _, trailer := range trailers {
+		if strings.EqualFold(trailer.Key, key) {
+			values = append(values, trailer.Value)
+		}
+	}
+	return values
+}
@@ -2,6 +2,8 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -13,20 +15,160 @@ type LineChange struct {
 
 // ChangeInfo represents file change information
 type ChangeInfo struct {
-	Action        string       `json:"action"`                   // Change type: add, delete, modify, rename, copy
-	Filepath      string       `json:"filepath"`                 // File path
-	OldPath       string       `json:"old_path,omitempty"`       // Original path for rename/copy
-	NewPath       string       `json:"new_path,omitempty"`       // New path for rename/copy
-	Additions     int          `json:"additions"`                // Number of added lines
-	Deletions     int          `json:"deletions"`                // Number of deleted lines
-	DiffContent   string       `json:"diff_content,omitempty"`   // Original diff content
-	Extension     string       `json:"extension,omitempty"`      // File extension
-	FileSize      int64        `json:"file_size,omitempty"`      // File size (bytes)
-	IsBinary      bool         `json:"is_binary,omitempty"`      // Whether it's a binary file
-	AdditionsList []LineChange `json:"additions_list,omitempty"` // Added lines
-	DeletionsList []LineChange `json:"deletions_list,omitempty"` // Deleted lines
-	IsFocus       bool         `json:"is_focus,omitempty"`       // Whether it's a focus file
-	FocusReason   string       `json:"focus_reason,omitempty"`   // Focus reason
+	Action        string            `json:"action"`                   // Change type: add, delete, modify, rename, copy
+	Filepath      string            `json:"filepath"`                 // File path
+	OldPath       string            `json:"old_path,omitempty"`       // Original path for rename/copy
+	NewPath       string            `json:"new_path,omitempty"`       // New path for rename/copy
+	Additions     int               `json:"additions"`                // Number of added lines
+	Deletions     int               `json:"deletions"`                // Number of deleted lines
+	DiffContent   string            `json:"diff_content,omitempty"`   // Original diff content
+	Extension     string            `json:"extension,omitempty"`      // File extension
+	FileSize      int64             `json:"file_size,omitempty"`      // File size (bytes)
+	BlobHash      string            `json:"blob_hash,omitempty"`      // Blob hash of the new content
+	OldFileSize   int64             `json:"old_file_size,omitempty"`  // File size before the change (bytes), set for binary files
+	OldBlobHash   string            `json:"old_blob_hash,omitempty"`  // Blob hash of the old content, set for binary files
+	OldMode       string            `json:"old_mode,omitempty"`       // File mode before the change, e.g. 100644
+	NewMode       string            `json:"new_mode,omitempty"`       // File mode after the change, e.g. 100755
+	Kind          string            `json:"kind,omitempty"`           // Special change kind: mode_change, symlink, submodule
+	Submodule     *SubmoduleChange  `json:"submodule,omitempty"`      // Submodule commits, set for submodule changes
+	LFS           *LFSChange        `json:"lfs,omitempty"`            // Git LFS objects, set for LFS pointer files
+	IsBinary      bool              `json:"is_binary,omitempty"`      // Whether it's a binary file
+	AdditionsList []LineChange      `json:"additions_list,omitempty"` // Added lines
+	DeletionsList []LineChange      `json:"deletions_list,omitempty"` // Deleted lines
+	Truncated     bool              `json:"truncated,omitempty"`      // Whether lines were omitted from the diff content and line lists
+	OmittedLines  int               `json:"omitted_lines,omitempty"`  // Number of changed lines omitted by truncation
+	Secrets       []SecretFinding   `json:"secrets,omitempty"`        // Secrets found in added lines
+	Template      *TemplateChange   `json:"template,omitempty"`       // Nuclei template changes, set for template files
+	Severity      *SeverityChange   `json:"severity,omitempty"`       // Change of the tracked severity field
+	IsFocus       bool              `json:"is_focus,omitempty"`       // Whether it's a focus file
+	FocusReason   string            `json:"focus_reason,omitempty"`   // Focus reason
+	Parent        string            `json:"parent,omitempty"`         // Parent the change is compared with, set for each-parent merge analysis
+	Attributes    map[string]string `json:"attributes,omitempty"`     // gitattributes of the file, "true" for set and "false" for unset attributes
+}
+
+// Special change kinds
+const (
+	KindModeChange = "mode_change" // File mode changed, e.g. executable bit flipped
+	KindSymlink    = "symlink"     // Symbolic link added, removed or retargeted
+	KindSubmodule  = "submodule"   // Submodule added, removed or its commit changed
+)
+
+// SubmoduleChange represents the submodule commits before and after a change
+type SubmoduleChange struct {
+	OldCommit string `json:"old_commit,omitempty"` // Submodule commit before the change
+	NewCommit string `json:"new_commit,omitempty"` // Submodule commit after the change
+}
+
+// LFSChange represents the Git LFS objects before and after a change of an LFS pointer file
+type LFSChange struct {
+	OldOID   string `json:"old_oid,omitempty"`  // Object id before the change, e.g. sha256:4d7a...
+	OldSize  int64  `json:"old_size,omitempty"` // Object size before the change (bytes)
+	NewOID   string `json:"new_oid,omitempty"`  // Object id after the change
+	NewSize  int64  `json:"new_size,omitempty"` // Object size after the change (bytes)
+	Resolved bool   `json:"resolved,omitempty"` // Whether the diff was built from the local LFS object store
+}
+
+// Template level changes of a nuclei template
+const (
+	TemplateNew                   = "new_template"
+	TemplateRemoved               = "template_removed"
+	TemplateSeverityChanged       = "severity_changed"
+	TemplateMatcherChanged        = "matcher_changed"
+	TemplateTagsChanged           = "tags_changed"
+	TemplateClassificationChanged = "classification_changed"
+)
+
+// TemplateInfo represents the metadata of a nuclei template
+type TemplateInfo struct {
+	ID        string   `json:"id"`                   // Template id
+	Name      string   `json:"name,omitempty"`       // Template name
+	Severity  string   `json:"severity,omitempty"`   // Severity, lower cased
+	Tags      []string `json:"tags,omitempty"`       // Tags
+	CVEIDs    []string `json:"cve_ids,omitempty"`    // CVE ids of the classification
+	CWEIDs    []string `json:"cwe_ids,omitempty"`    // CWE ids of the classification
+	CVSSScore float64  `json:"cvss_score,omitempty"` // CVSS score of the classification
+	Protocols []string `json:"protocols,omitempty"`  // Protocol sections, e.g. http, dns, network
+	Matchers  int      `json:"matchers,omitempty"`   // Number of matchers of all protocol sections
+}
+
+// TemplateChange represents the template level changes of a nuclei template file
+type TemplateChange struct {
+	Changes     []string      `json:"changes"`                // Template level changes, empty if only other lines changed
+	Template    *TemplateInfo `json:"template"`               // Template after the change, before it for removed templates
+	OldID       string        `json:"old_id,omitempty"`       // Template id before the change, set if the id changed
+	OldSeverity string        `json:"old_severity,omitempty"` // Severity before the change, set if the severity changed
+	AddedTags   []string      `json:"added_tags,omitempty"`   // Tags added by the change
+	RemovedTags []string      `json:"removed_tags,omitempty"` // Tags removed by the change
+}
+
+// Severity change directions
+const (
+	SeverityEscalation   = "escalation"    // The severity moved up the scale
+	SeverityDeescalation = "de-escalation" // The severity moved down the scale
+	SeverityChanged      = "changed"       // A severity is not on the scale
+)
+
+// SeverityChange represents a change of the severity field of a structured file
+type SeverityChange struct {
+	File        string `json:"file"`            // File path
+	Field       string `json:"field"`           // Severity field, e.g. info.severity
+	OldSeverity string `json:"old_severity"`    // Severity before the change, lower cased
+	NewSeverity string `json:"new_severity"`    // Severity after the change, lower cased
+	Direction   string `json:"direction"`       // escalation, de-escalation or changed
+	Steps       int    `json:"steps,omitempty"` // Positions moved on the scale, negative for de-escalations
+}
+
+// SecretFinding represents a secret found in an added line
+type SecretFinding struct {
+	Detector string `json:"detector"` // Name of the detector that found the secret
+	File     string `json:"file"`     // File path
+	Line     int    `json:"line"`     // Line number in the new file
+	Match    string `json:"match"`    // Redacted secret, or the header of a private key
+}
+
+// Focus severities, from lowest to highest
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Author trust levels of the focus author lists
+const (
+	TrustTrusted        = "trusted"         // Author matches the trusted authors
+	TrustUntrusted      = "untrusted"       // Author matches the untrusted authors
+	TrustNewContributor = "new_contributor" // First commit by the author email
+)
+
+// Signature formats
+const (
+	SignatureOpenPGP = "openpgp"
+	SignatureSSH     = "ssh"
+	SignatureX509    = "x509"
+)
+
+// Signature verification statuses, SignatureUnsigned is only used by focus rules
+const (
+	SignatureGood       = "good"        // Valid signature by a configured key
+	SignatureBad        = "bad"         // Invalid or malformed signature
+	SignatureUnknownKey = "unknown_key" // Signing key is not configured
+	SignatureUnverified = "unverified"  // No keys configured for the signature format
+	SignatureUnsigned   = "unsigned"    // No signature
+)
+
+// SignatureInfo represents a commit or tag signature and its verification
+type SignatureInfo struct {
+	Format string `json:"format,omitempty"` // Signature format: openpgp, ssh or x509
+	Status string `json:"status"`           // Verification status: good, bad, unknown_key or unverified
+	KeyID  string `json:"key_id,omitempty"` // OpenPGP issuer key id or SSH key fingerprint
+	Signer string `json:"signer,omitempty"` // OpenPGP user id or SSH principals of the signing key
+	Error  string `json:"error,omitempty"`  // Verification error
+}
+
+// TagSignatureInfo represents the signature of an annotated tag
+type TagSignatureInfo struct {
+	Tag string `json:"tag"` // Tag name
+	SignatureInfo
 }
 
 // FocusFileInfo represents focus file information
@@ -34,10 +176,19 @@ type FocusFileInfo struct {
 	Filepath   string   `json:"filepath"`              // File path
 	Action     string   `json:"action"`                // Change type
 	Reason     string   `json:"reason"`                // Focus reason
+	Severity   string   `json:"severity,omitempty"`    // Focus severity: low, medium or high
 	MatchCount int      `json:"match_count,omitempty"` // Number of matched content
 	MatchLines []string `json:"match_lines,omitempty"` // Matched line content (summary)
 }
 
+// MessageFocusInfo represents a focus message rule matching the commit message
+type MessageFocusInfo struct {
+	Rule   string `json:"rule"`   // Rule name, or its pattern if unnamed
+	Field  string `json:"field"`  // Matched message field
+	Action string `json:"action"` // Rule action: "focus" or "suppress"
+	Match  string `json:"match"`  // Matched text (summary)
+}
+
 // AuthorInfo represents author/committer information
 type AuthorInfo struct {
 	Name  string `json:"name"`
@@ -49,66 +200,237 @@ type AuthorInfo struct {
 type StatsInfo struct {
 	TotalAdditions int `json:"total_additions"` // Total added lines
 	TotalDeletions int `json:"total_deletions"` // Total deleted lines
-	TotalFiles     int `json:"total_files"`     // Total changed files
+	TotalFiles     int `json:"total_files"`     // Total changed files, once per parent for each-parent merges
+	UniqueFiles    int `json:"unique_files"`    // Number of distinct changed file paths
 	AddFiles       int `json:"add_files"`       // Number of added files
 	DeleteFiles    int `json:"delete_files"`    // Number of deleted files
 	ModifyFiles    int `json:"modify_files"`    // Number of modified files
 	RenameFiles    int `json:"rename_files"`    // Number of renamed files
 	CopyFiles      int `json:"copy_files"`      // Number of copied files
 	BinaryFiles    int `json:"binary_files"`    // Number of binary files
+	LFSFiles       int `json:"lfs_files"`       // Number of Git LFS pointer files
+	FilteredFiles  int `json:"filtered_files"`  // Number of changed files dropped by path filters
+	SecretFindings int `json:"secret_findings"` // Number of secrets found in added lines
+	Escalations    int `json:"escalations"`     // Number of severity escalations
+	Deescalations  int `json:"deescalations"`   // Number of severity de-escalations
 }
 
 // FocusStats represents focus statistics
 type FocusStats struct {
-	TotalFocusFiles   int `json:"total_focus_files"`   // Total focus files
+	TotalFocusFiles   int `json:"total_focus_files"`   // Total focus files, once per parent for each-parent merges
+	UniqueFocusFiles  int `json:"unique_focus_files"`  // Number of distinct focus file paths
 	AddFocusFiles     int `json:"add_focus_files"`     // Number of new focus files
 	ModifyFocusFiles  int `json:"modify_focus_files"`  // Number of modified focus files
 	DeleteFocusFiles  int `json:"delete_focus_files"`  // Number of deleted focus files
 	MatchPatternFiles int `json:"match_pattern_files"` // Number of files matching pattern
 	MatchContentFiles int `json:"match_content_files"` // Number of files matching content
+	FocusCommits      int `json:"focus_commits"`       // Number of commits marked as focus by message rules
+	SuppressedFiles   int `json:"suppressed_files"`    // Number of focus files suppressed by message rules
 }
 
 // DiffSummary represents diff summary information
 type DiffSummary struct {
-	TotalDiffSize int    `json:"total_diff_size"`          // Total diff size
-	DiffTooLarge  bool   `json:"diff_too_large,omitempty"` // Whether diff is too large
-	MaxDiffSize   int    `json:"max_diff_size,omitempty"`  // Maximum diff size limit
-	FullDiff      string `json:"full_diff,omitempty"`      // Complete diff content
+	TotalDiffSize  int    `json:"total_diff_size"`           // Total diff size
+	DiffTooLarge   bool   `json:"diff_too_large,omitempty"`  // Whether diff is too large
+	MaxDiffSize    int    `json:"max_diff_size,omitempty"`   // Maximum diff size limit
+	TruncatedFiles int    `json:"truncated_files,omitempty"` // Number of files with a truncated diff
+	FullDiff       string `json:"full_diff,omitempty"`       // Complete diff content
 }
 
 // CommitInfo represents complete commit information
 type CommitInfo struct {
-	Hash         string          `json:"hash"`                   // Commit hash
-	ShortHash    string          `json:"short_hash"`             // Short hash
-	Author       AuthorInfo      `json:"author"`                 // Author information
-	Committer    AuthorInfo      `json:"committer"`              // Committer information
-	Message      string          `json:"message"`                // Commit message subject
-	Description  string          `json:"description"`            // Detailed description
-	FullMessage  string          `json:"full_message"`           // Full commit message
-	ParentHashes []string        `json:"parent_hashes"`          // Parent commit hash list
-	Changes      []ChangeInfo    `json:"changes"`                // Change content list
-	FocusFiles   []FocusFileInfo `json:"focus_files,omitempty"`  // Focus change file list
-	Timestamp    int64           `json:"timestamp"`              // Commit timestamp
-	TreeHash     string          `json:"tree_hash"`              // Tree object hash
-	FilesChanged []string        `json:"files_changed"`          // Changed file list
-	Stats        StatsInfo       `json:"stats"`                  // Statistics
-	DiffSummary  DiffSummary     `json:"diff_summary"`           // Diff summary
-	Branches     []string        `json:"branches,omitempty"`     // Belonging branches
-	Tags         []string        `json:"tags,omitempty"`         // Tags
-	OutputFile   string          `json:"output_file,omitempty"`  // Output file path
-	AnalyzeTime  string          `json:"analyze_time,omitempty"` // Analysis time
-	FocusStats   FocusStats      `json:"focus_stats,omitempty"`  // Focus statistics
+	Hash            string             `json:"hash"`                       // Commit hash
+	ShortHash       string             `json:"short_hash"`                 // Short hash
+	Author          AuthorInfo         `json:"author"`                     // Author information
+	Committer       AuthorInfo         `json:"committer"`                  // Committer information
+	Message         string             `json:"message"`                    // Commit message subject
+	Description     string             `json:"description"`                // Detailed description
+	FullMessage     string             `json:"full_message"`               // Full commit message
+	Conventional    *ConventionalInfo  `json:"conventional,omitempty"`     // Conventional Commits header
+	Trailers        []TrailerInfo      `json:"trailers,omitempty"`         // Trailers of the commit message
+	ParentHashes    []string           `json:"parent_hashes"`              // Parent commit hash list
+	MergeStrategy   string             `json:"merge_strategy,omitempty"`   // Merge strategy applied to a merge commit
+	Changes         []ChangeInfo       `json:"changes"`                    // Change content list
+	FocusFiles      []FocusFileInfo    `json:"focus_files,omitempty"`      // Focus change file list
+	IsFocus         bool               `json:"is_focus,omitempty"`         // Whether a message rule marked the commit as focus
+	MessageFocus    []MessageFocusInfo `json:"message_focus,omitempty"`    // Message rules matching the commit message
+	AuthorTrust     string             `json:"author_trust,omitempty"`     // Author trust level: trusted, untrusted or new_contributor
+	Signature       *SignatureInfo     `json:"signature,omitempty"`        // Commit signature, nil if unsigned
+	TagSignatures   []TagSignatureInfo `json:"tag_signatures,omitempty"`   // Signatures of annotated tags pointing at the commit
+	Secrets         []SecretFinding    `json:"secrets,omitempty"`          // Secrets found in added lines
+	SeverityChanges []SeverityChange   `json:"severity_changes,omitempty"` // Severity changes of structured files
+	Timestamp       int64              `json:"timestamp"`                  // Commit timestamp
+	TreeHash        string             `json:"tree_hash"`                  // Tree object hash
+	FilesChanged    []string           `json:"files_changed"`              // Changed file list
+	Stats           StatsInfo          `json:"stats"`                      // Statistics
+	DiffSummary     DiffSummary        `json:"diff_summary"`               // Diff summary
+	Branches        []string           `json:"branches,omitempty"`         // Belonging branches
+	Tags            []string           `json:"tags,omitempty"`             // Tags
+	Describe        *DescribeInfo      `json:"describe,omitempty"`         // Nearest preceding tag
+	OutputFile      string             `json:"output_file,omitempty"`      // Output file path
+	AnalyzeTime     string             `json:"analyze_time,omitempty"`     // Analysis time
+	FocusStats      FocusStats         `json:"focus_stats,omitempty"`      // Focus statistics
+}
+
+// DescribeInfo represents the nearest tag reachable from a commit, like git describe --tags
+type DescribeInfo struct {
+	Tag         string `json:"tag"`         // Nearest tag
+	Distance    int    `json:"distance"`    // Number of commits since the tag
+	Description string `json:"description"` // Description in git describe format, e.g. v1.2.0-3-gabc1234
+}
+
+// BatchRepoResult represents the analysis result of one repository in a batch
+type BatchRepoResult struct {
+	Name       string     `json:"name"`                  // Repository name
+	Path       string     `json:"path,omitempty"`        // Local repository path
+	URL        string     `json:"url,omitempty"`         // Remote repository URL
+	Branch     string     `json:"branch,omitempty"`      // Analyzed branch
+	Hash       string     `json:"hash,omitempty"`        // Analyzed commit hash
+	ShortHash  string     `json:"short_hash,omitempty"`  // Short hash
+	Message    string     `json:"message,omitempty"`     // Commit message subject
+	OutputFile string     `json:"output_file,omitempty"` // Report file path
+	Error      string     `json:"error,omitempty"`       // Error message if analysis failed
+	Stats      StatsInfo  `json:"stats"`                 // Statistics
+	FocusStats FocusStats `json:"focus_stats"`           // Focus statistics
+}
+
+// BatchSummary represents the combined summary of a batch analysis
+type BatchSummary struct {
+	AnalyzeTime  string            `json:"analyze_time"`  // Analysis time
+	TotalRepos   int               `json:"total_repos"`   // Total repositories
+	SuccessRepos int               `json:"success_repos"` // Successfully analyzed repositories
+	FailedRepos  int               `json:"failed_repos"`  // Failed repositories
+	FocusStats   FocusStats        `json:"focus_stats"`   // Focus statistics of all repositories
+	Repos        []BatchRepoResult `json:"repos"`         // Per repository results
+	OutputFile   string            `json:"output_file,omitempty"`
+}
+
+// RangeCommitResult represents the analysis result of one commit in a range analysis
+type RangeCommitResult struct {
+	Hash       string     `json:"hash"`                  // Commit hash
+	ShortHash  string     `json:"short_hash"`            // Short hash
+	Message    string     `json:"message,omitempty"`     // Commit message subject
+	Author     string     `json:"author,omitempty"`      // Author name
+	Timestamp  int64      `json:"timestamp,omitempty"`   // Commit timestamp
+	OutputFile string     `json:"output_file,omitempty"` // Report file path
+	Error      string     `json:"error,omitempty"`       // Error message if analysis failed
+	Skipped    string     `json:"skipped,omitempty"`     // Reason the commit filters skipped the commit
+	Stats      StatsInfo  `json:"stats"`                 // Statistics
+	FocusStats FocusStats `json:"focus_stats"`           // Focus statistics
+}
+
+// RangeSummary represents the combined summary of a range analysis
+type RangeSummary struct {
+	RepoPath        string              `json:"repo_path"`                          // Repository path
+	CommitRange     string              `json:"commit_range"`                       // Analyzed commit range
+	AnalyzeTime     string              `json:"analyze_time"`                       // Analysis time
+	TotalCommits    int                 `json:"total_commits"`                      // Total commits in range
+	SuccessCommits  int                 `json:"success_commits"`                    // Successfully analyzed commits
+	FailedCommits   int                 `json:"failed_commits"`                     // Failed commits
+	SkippedCommits  int                 `json:"skipped_commits"`                    // Commits skipped by the commit filters
+	UnlistedSkipped int                 `json:"unlisted_skipped_commits,omitempty"` // Skipped commits not listed in commits
+	Canceled        bool                `json:"canceled,omitempty"`
+	FocusStats      FocusStats          `json:"focus_stats"` // Focus statistics of all commits
+	Commits         []RangeCommitResult `json:"commits"`     // Per commit results, newest first
+	OutputFile      string              `json:"output_file,omitempty"`
+}
+
+// ToJSON converts RangeSummary to JSON string
+func (r *RangeSummary) ToJSON(pretty bool) (string, error) {
+	return marshalJSON(r, pretty)
+}
+
+// Feed entry kinds
+const (
+	FeedAdded   = "added"   // The CVE entry was added in the range
+	FeedChanged = "changed" // An existing CVE entry was materially changed in the range
+)
+
+// FeedEntry represents a CVE entry of the vulnerability feed
+type FeedEntry struct {
+	CVEID     string   `json:"cve_id"`             // CVE id
+	Kind      string   `json:"kind"`               // Entry kind: added, changed
+	Severity  string   `json:"severity,omitempty"` // Severity of the template, empty if the file is no template
+	Name      string   `json:"name,omitempty"`     // Template name
+	File      string   `json:"file"`               // File path
+	Changes   []string `json:"changes,omitempty"`  // Template level changes of the newest commit
+	Commit    string   `json:"commit"`             // Hash of the newest commit changing the entry
+	ShortHash string   `json:"short_hash"`         // Short hash of the commit
+	Author    string   `json:"author"`             // Author name of the commit
+	Email     string   `json:"email,omitempty"`    // Author email of the commit
+	Date      string   `json:"date"`               // Commit date, RFC 3339
+}
+
+// FeedSummary represents the result of a vulnerability feed run
+type FeedSummary struct {
+	RepoPath        string      `json:"repo_path"`                          // Repository path
+	CommitRange     string      `json:"commit_range"`                       // Walked commit range
+	AnalyzeTime     string      `json:"analyze_time"`                       // Analysis time
+	TotalCommits    int         `json:"total_commits"`                      // Total commits in range
+	FailedCommits   int         `json:"failed_commits"`                     // Failed commits
+	SkippedCommits  int         `json:"skipped_commits"`                    // Commits skipped by the commit filters
+	UnlistedSkipped int         `json:"unlisted_skipped_commits,omitempty"` // Skipped commits not passed to the feed
+	Canceled        bool        `json:"canceled,omitempty"`
+	Entries         []FeedEntry `json:"entries"`                // Deduplicated CVE entries, newest first
+	OutputFiles     []string    `json:"output_files,omitempty"` // Written feed files
+}
+
+// ToJSON converts FeedSummary to JSON string
+func (f *FeedSummary) ToJSON(pretty bool) (string, error) {
+	return marshalJSON(f, pretty)
+}
+
+// Add adds other change statistics to s
+func (s *StatsInfo) Add(other StatsInfo) {
+	s.TotalAdditions += other.TotalAdditions
+	s.TotalDeletions += other.TotalDeletions
+	s.TotalFiles += other.TotalFiles
+	s.UniqueFiles += other.UniqueFiles
+	s.AddFiles += other.AddFiles
+	s.DeleteFiles += other.DeleteFiles
+	s.ModifyFiles += other.ModifyFiles
+	s.RenameFiles += other.RenameFiles
+	s.CopyFiles += other.CopyFiles
+	s.BinaryFiles += other.BinaryFiles
+	s.LFSFiles += other.LFSFiles
+	s.FilteredFiles += other.FilteredFiles
+	s.SecretFindings += other.SecretFindings
+	s.Escalations += other.Escalations
+	s.Deescalations += other.Deescalations
+}
+
+// Add adds other focus statistics to s
+func (s *FocusStats) Add(other FocusStats) {
+	s.TotalFocusFiles += other.TotalFocusFiles
+	s.UniqueFocusFiles += other.UniqueFocusFiles
+	s.AddFocusFiles += other.AddFocusFiles
+	s.ModifyFocusFiles += other.ModifyFocusFiles
+	s.DeleteFocusFiles += other.DeleteFocusFiles
+	s.MatchPatternFiles += other.MatchPatternFiles
+	s.MatchContentFiles += other.MatchContentFiles
+	s.FocusCommits += other.FocusCommits
+	s.SuppressedFiles += other.SuppressedFiles
+}
+
+// ToJSON converts BatchSummary to JSON string
+func (b *BatchSummary) ToJSON(pretty bool) (string, error) {
+	return marshalJSON(b, pretty)
 }
 
 // ToJSON converts CommitInfo to JSON string
 func (c *CommitInfo) ToJSON(pretty bool) (string, error) {
+	return marshalJSON(c, pretty)
+}
+
+// marshalJSON converts value to JSON string
+func marshalJSON(v interface{}, pretty bool) (string, error) {
 	var data []byte
 	var err error
 
 	if pretty {
-		data, err = json.MarshalIndent(c, "", "  ")
+		data, err = json.MarshalIndent(v, "", "  ")
 	} else {
-		data, err = json.Marshal(c)
+		data, err = json.Marshal(v)
 	}
 
 	if err != nil {
@@ -155,27 +477,6 @@ func GetFileExtension(filename string) string {
 	return ""
 }
 
-// IsLikelyBinaryFile checks if file is likely binary
-func IsLikelyBinaryFile(filename string) bool {
-	// Common binary file extensions
-	binaryExtensions := map[string]bool{
-		"exe": true, "dll": true, "so": true, "dylib": true,
-		"bin": true, "class": true, "jar": true, "war": true,
-		"png": true, "jpg": true, "jpeg": true, "gif": true,
-		"bmp": true, "ico": true, "pdf": true, "doc": true,
-		"docx": true, "xls": true, "xlsx": true, "ppt": true,
-		"pptx": true, "zip": true, "tar": true, "gz": true,
-		"7z": true, "rar": true, "mp3": true, "mp4": true,
-		"avi": true, "mkv": true, "mov": true, "wav": true,
-		"iso": true, "img": true, "dmg": true, "pkg": true,
-		"o": true, "obj": true, "lib": true, "a": true,
-	}
-
-	ext := GetFileExtension(filename)
-	ext = strings.ToLower(ext)
-	return binaryExtensions[ext]
-}
-
 // TruncateString truncates string
 func TruncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -193,3 +494,17 @@ func Contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+// CompileRegexps compiles the regular expressions of a configuration list, name is
+// the list name used in errors
+func CompileRegexps(name string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", name, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/signal"
 
+	"warmy/internal/batch"
 	"warmy/internal/config"
+	"warmy/internal/feed"
 	"warmy/internal/git"
 	"warmy/internal/logger"
+	"warmy/internal/output"
+	"warmy/internal/pipeline"
+)
+
+var (
+	// command selected on the command line, empty for single commit analysis
+	command string
+	// config file and profile given on the command line
+	configFile  string
+	profileName string
+	// commit range given on the command line, overrides commit_range of the config file
+	rangeArg string
 )
 
 func main() {
@@ -26,12 +41,16 @@ func main() {
 	}
 
 	// Load configuration file
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(configFile, profileName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if rangeArg != "" {
+		cfg.CommitRange = rangeArg
+	}
+
 	// Initialize logger
 	logger.InitLogger(cfg.LogLevel)
 	log := logger.GetLogger()
@@ -41,10 +60,36 @@ func main() {
 		"author":      "https://github.com/Applenice",
 		"version":     "1.0.0",
 		"config_file": cfg.ConfigFile,
+		"profile":     cfg.Profile,
+		"command":     command,
 	}).Info("Program started")
 
+	// Stop analysis on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if command == "batch" {
+		runBatch(ctx, cfg, log)
+		return
+	}
+
+	analyzer, err := git.New(cfg, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize analyzer")
+	}
+
+	if command == "range" {
+		runRange(ctx, analyzer, log)
+		return
+	}
+
+	if command == "feed" {
+		runFeed(ctx, analyzer, log)
+		return
+	}
+
 	// Get specified commit information
-	commitInfo, err := git.GetCommit(cfg.RepoPath, cfg.CommitHash)
+	commitInfo, err := analyzer.GetCommit(ctx, cfg.RepoPath, cfg.CommitHash)
 	if err != nil {
 		log.WithFields(logger.Fields{
 			"repo_path":   cfg.RepoPath,
@@ -54,7 +99,7 @@ func main() {
 	}
 
 	// Format as JSON
-	jsonOutput, err := commitInfo.ToJSON(cfg.PrettyJSON)
+	jsonOutput, err := commitInfo.ToJSON(cfg.PrettyJSON.Value())
 	if err != nil {
 		log.WithError(err).Fatal("Failed to format JSON")
 	}
@@ -68,27 +113,23 @@ func main() {
 	commitInfo.OutputFile = outputFilename
 
 	// Reformat as JSON (including output file path)
-	jsonOutput, err = commitInfo.ToJSON(cfg.PrettyJSON)
+	jsonOutput, err = commitInfo.ToJSON(cfg.PrettyJSON.Value())
 	if err != nil {
 		log.WithError(err).Fatal("Failed to reformat JSON")
 	}
 
 	// Output result to console
-	if !cfg.NoConsole {
+	if !cfg.NoConsole.Value() {
 		fmt.Println(jsonOutput)
 		log.Info("JSON data output to console")
 	}
 
 	// Save result to file
-	if !cfg.NoFile {
-		err := saveJSONToFile(cfg.OutputDir, outputFilename, jsonOutput)
+	if !cfg.NoFile.Value() {
+		fullPath, err := output.SaveJSONToFile(cfg.OutputDir, outputFilename, jsonOutput)
 		if err != nil {
 			log.WithError(err).Error("Failed to save to file")
 		} else {
-			fullPath := outputFilename
-			if cfg.OutputDir != "." && cfg.OutputDir != "" {
-				fullPath = filepath.Join(cfg.OutputDir, outputFilename)
-			}
 			log.WithFields(logger.Fields{
 				"filename": outputFilename,
 				"filepath": fullPath,
@@ -99,52 +140,125 @@ func main() {
 	log.Info("Program execution completed")
 }
 
+// runBatch analyzes all configured repositories
+func runBatch(ctx context.Context, cfg *config.Config, log logger.Logger) {
+	summary, err := batch.Run(ctx, cfg, log)
+	if err != nil {
+		log.WithError(err).Fatal("Batch analysis failed")
+	}
+
+	// Output summary to console
+	if !cfg.NoConsole.Value() {
+		jsonOutput, err := summary.ToJSON(cfg.PrettyJSON.Value())
+		if err != nil {
+			log.WithError(err).Fatal("Failed to format JSON")
+		}
+		fmt.Println(jsonOutput)
+		log.Info("Batch summary output to console")
+	}
+
+	log.Info("Program execution completed")
+
+	if summary.FailedRepos > 0 {
+		os.Exit(1)
+	}
+}
+
+// runRange analyzes all commits of the configured commit range
+func runRange(ctx context.Context, analyzer *git.Analyzer, log logger.Logger) {
+	cfg := analyzer.Config()
+
+	// On interrupt the partial summary is still saved
+	summary, err := pipeline.Run(ctx, analyzer)
+	if err != nil {
+		log.WithError(err).Fatal("Range analysis failed")
+	}
+
+	// Output summary to console
+	if !cfg.NoConsole.Value() {
+		jsonOutput, err := summary.ToJSON(cfg.PrettyJSON.Value())
+		if err != nil {
+			log.WithError(err).Fatal("Failed to format JSON")
+		}
+		fmt.Println(jsonOutput)
+		log.Info("Range summary output to console")
+	}
+
+	log.Info("Program execution completed")
+
+	if summary.Canceled || summary.FailedCommits > 0 {
+		os.Exit(1)
+	}
+}
+
+// runFeed writes the vulnerability feed of the configured commit range
+func runFeed(ctx context.Context, analyzer *git.Analyzer, log logger.Logger) {
+	cfg := analyzer.Config()
+
+	// On interrupt the feed of the analyzed commits is still saved
+	summary, err := feed.Run(ctx, analyzer)
+	if err != nil {
+		log.WithError(err).Fatal("Feed generation failed")
+	}
+
+	// Output summary to console
+	if !cfg.NoConsole.Value() {
+		jsonOutput, err := summary.ToJSON(cfg.PrettyJSON.Value())
+		if err != nil {
+			log.WithError(err).Fatal("Failed to format JSON")
+		}
+		fmt.Println(jsonOutput)
+		log.Info("Feed summary output to console")
+	}
+
+	log.Info("Program execution completed")
+
+	if summary.Canceled || summary.FailedCommits > 0 {
+		os.Exit(1)
+	}
+}
+
 // parseArgs parses command line arguments
 func parseArgs() error {
-	// Only parse --config parameter
+	// Parse command, --config and --profile parameters
 	for i := 1; i < len(os.Args); i++ {
 		arg := os.Args[i]
 		switch arg {
+		case "batch", "range", "feed":
+			if command != "" {
+				return fmt.Errorf("unexpected argument: %s", arg)
+			}
+			command = arg
+		case "--range":
+			if i+1 < len(os.Args) {
+				rangeArg = os.Args[i+1]
+				i++
+			} else {
+				return fmt.Errorf("--range parameter requires specifying commit range")
+			}
 		case "-h", "--help":
 			return fmt.Errorf("show_help")
 		case "-v", "--version":
 			return fmt.Errorf("show_version")
 		case "--config":
 			if i+1 < len(os.Args) {
-				config.SetConfigFile(os.Args[i+1])
+				configFile = os.Args[i+1]
 				i++
 			} else {
 				return fmt.Errorf("--config parameter requires specifying config file path")
 			}
+		case "--profile":
+			if i+1 < len(os.Args) {
+				profileName = os.Args[i+1]
+				i++
+			} else {
+				return fmt.Errorf("--profile parameter requires specifying profile name")
+			}
 		}
 	}
 	return nil
 }
 
-// saveJSONToFile saves JSON to file
-func saveJSONToFile(dir, filename, data string) error {
-	// Ensure directory exists
-	if dir != "." && dir != "" {
-		err := os.MkdirAll(dir, 0755)
-		if err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-	}
-
-	// Build complete file path
-	filepath := filename
-	if dir != "." && dir != "" {
-		filepath = dir + "/" + filename
-	}
-
-	err := os.WriteFile(filepath, []byte(data), 0644)
-	if err != nil {
-		return fmt.Errorf("failed to save to file: %w", err)
-	}
-
-	return nil
-}
-
 // printHelp prints help information
 func printHelp() {
 	helpText := `Warmy Git Commit Reader v1.0.0
@@ -155,11 +269,24 @@ A configuration file driven Git commit analysis tool with focus feature support.
 
 Usage:
   warmy [options]
+  warmy batch [options]
+  warmy range [options] [--range A..B]
+  warmy feed [options] [--range A..B]
+
+Commands:
+  batch             Analyze every repository in the repos list of the configuration file
+                    and write per repository reports plus a combined summary
+  range             Analyze every commit of a commit range in parallel
+                    and write per commit reports plus a combined summary
+  feed              Walk a commit range and write a feed of the CVE entries
+                    added or materially changed in it (JSON Feed, Atom or RSS)
 
 Options:
   -h, --help        Show help information
   -v, --version     Show version information
   --config FILE     Specify configuration file path (optional, defaults to config.json in current directory)
+  --profile NAME    Apply named profile from configuration file (optional)
+  --range A..B      Commit range for the range and feed commands, overrides commit_range (optional)
 
 Configuration file:
   The program will look for config.json configuration file in the current directory.
@@ -172,6 +299,18 @@ Examples:
   # Specify configuration file
   warmy --config config.json
   
+  # Use a named profile
+  warmy --config config.json --profile nuclei
+  
+  # Analyze all configured repositories
+  warmy batch --config config.json
+  
+  # Analyze the commits since a tag
+  warmy range --config config.json --range v1.2.0..main
+  
+  # Write a feed of the CVE templates added in the last 100 commits
+  warmy feed --config config.json --range HEAD~100..HEAD
+  
   # Show help
   warmy --help
   
@@ -0,0 +1,139 @@
+// Package warmy provides the Git commit analysis of the warmy command as a library.
+//
+// An Analyzer is created from an options struct and holds no global state, so
+// several analyzers with different settings can run in the same process and a
+// single analyzer can be used from multiple goroutines.
+package warmy
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+
+	"warmy/internal/config"
+	wgit "warmy/internal/git"
+	"warmy/internal/logger"
+	"warmy/internal/pipeline"
+	"warmy/internal/types"
+)
+
+// Configuration, report and logging types shared with the warmy command
+type (
+	Config                 = config.Config
+	FocusConfig            = config.FocusConfig
+	MessageRule            = config.MessageRule
+	RootCommitConfig       = config.RootCommitConfig
+	LFSConfig              = config.LFSConfig
+	PathFilterConfig       = config.PathFilterConfig
+	CommitFilterConfig     = config.CommitFilterConfig
+	SignatureConfig        = config.SignatureConfig
+	SecretsConfig          = config.SecretsConfig
+	SecretDetector         = config.SecretDetector
+	TemplateConfig         = config.TemplateConfig
+	SeverityTrackingConfig = config.SeverityTrackingConfig
+	FeedConfig             = config.FeedConfig
+	OptionalBool           = config.OptionalBool
+	CommitInfo             = types.CommitInfo
+	ChangeInfo             = types.ChangeInfo
+	FocusFileInfo          = types.FocusFileInfo
+	Logger                 = logger.Logger
+	Fields                 = logger.Fields
+	RangeResult            = pipeline.Result
+)
+
+// Options analyzer options
+type Options struct {
+	Config *Config      // Analysis configuration merged over DefaultConfig, nil means defaults
+	Focus  *FocusConfig // Focus rules merged over Config.Focus, nil keeps Config.Focus
+	Logger Logger       // Logger, nil discards all log output
+}
+
+// Analyzer analyzes commits of local repositories.
+// It is safe for concurrent use.
+type Analyzer struct {
+	analyzer *wgit.Analyzer
+}
+
+// New creates an analyzer from options
+func New(opts Options) (*Analyzer, error) {
+	cfg := config.DefaultConfig()
+	config.Merge(&cfg, opts.Config)
+	config.MergeFocus(&cfg.Focus, opts.Focus)
+
+	log := opts.Logger
+	if log == nil {
+		log = logger.Discard()
+	}
+
+	analyzer, err := wgit.New(&cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Analyzer{analyzer: analyzer}, nil
+}
+
+// Config returns the effective configuration of the analyzer, it must not be modified
+func (a *Analyzer) Config() *Config {
+	return a.analyzer.Config()
+}
+
+// AnalyzeCommit analyzes a single commit of the repository at repoPath.
+// An empty rev means HEAD. The repository is opened for every call.
+func (a *Analyzer) AnalyzeCommit(ctx context.Context, repoPath, rev string) (*CommitInfo, error) {
+	return a.analyzer.GetCommit(ctx, repoPath, rev)
+}
+
+// AnalyzeRange analyzes every commit of commitRange ("A..B", a single revision
+// for its history, or empty for the history of HEAD) using Config.CommitWorkers
+// workers, limited to Config.MaxCommits commits. Results are passed to fn in
+// range order, newest first; returning an error from fn stops the analysis.
+// Commits skipped by Config.CommitFilter are passed with RangeResult.Skipped set,
+// past the first 1000 they are only counted in RangeResult.Unlisted.
+func (a *Analyzer) AnalyzeRange(ctx context.Context, repoPath, commitRange string, fn func(RangeResult) error) error {
+	cfg := a.analyzer.Config()
+
+	return pipeline.Execute(ctx, a.analyzer, pipeline.Options{
+		RepoPath:    repoPath,
+		CommitRange: commitRange,
+		MaxCommits:  cfg.MaxCommits,
+		Workers:     cfg.CommitWorkers,
+	}, fn)
+}
+
+// ResolveCommit resolves a revision of the repository at repoPath to its full commit hash
+func (a *Analyzer) ResolveCommit(repoPath, rev string) (string, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open local repository: %w", err)
+	}
+
+	commit, err := a.analyzer.ResolveCommit(repo, rev)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+// DefaultConfig returns the built-in default configuration
+func DefaultConfig() Config {
+	return config.DefaultConfig()
+}
+
+// LoadConfig loads configuration from file merged over the defaults, applying
+// includes and the given profile (empty for the profile named in the file)
+func LoadConfig(configFile, profile string) (*Config, error) {
+	return config.LoadConfig(configFile, profile)
+}
+
+// Bool returns an OptionalBool explicitly set to v
+func Bool(v bool) OptionalBool {
+	return config.Bool(v)
+}
+
+// NewLogger creates a logger with the given level writing to out
+func NewLogger(level string, out io.Writer) Logger {
+	return logger.New(level, out)
+}